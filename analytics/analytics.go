@@ -0,0 +1,240 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package analytics aggregates run event logs to find out where and why
+// players die, reporting text tables and heatmaps of the tunnel cross-section.
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+// Options control how the runs are aggregated.
+type Options struct {
+	// MinX, MaxX, MinY and MaxY are the bounds of the tunnel cross-section
+	// covered by the heatmaps.
+	MinX, MaxX float32
+	MinY, MaxY float32
+
+	// CellSize is the size of a heatmap cell in meters.
+	CellSize float32
+
+	// DistanceBucket is the size of the distance buckets in meters.
+	DistanceBucket float64
+
+	// WaveBucket is the size of the time since the last spawn wave buckets
+	// in seconds.
+	WaveBucket float64
+}
+
+// Death is where and how a run ended.
+type Death struct {
+	// Run is the name of the log the death came from.
+	Run string
+
+	// X and Y are the location of the ship in the tunnel cross-section.
+	X, Y float32
+
+	Distance float64
+	Time     float64
+
+	// Cause is the name of the entity that killed the ship and Component is
+	// the component it was created from, such as the type of bomb.
+	Cause     string
+	Component string
+
+	// SinceWave is the time since the last wave of bombs was spawned, or -1
+	// if there wasn't one.
+	SinceWave float64
+}
+
+// Report is the aggregate of a set of runs.
+type Report struct {
+	Options Options
+
+	Runs   int
+	Deaths []Death
+
+	// the heatmaps of the tunnel cross-section
+	DeathMap    *Grid
+	SpawnMap    *Grid
+	NearMissMap *Grid
+}
+
+// NewReport returns a new empty report.
+func NewReport(opts Options) *Report {
+	r := new(Report)
+	r.Options = opts
+	r.DeathMap = NewGrid(opts)
+	r.SpawnMap = NewGrid(opts)
+	r.NearMissMap = NewGrid(opts)
+	return r
+}
+
+// AddRun adds the events of a run log to the report.
+func (r *Report) AddRun(name string, events []telemetry.Event) {
+	r.Runs++
+	lastWave := -1.0
+
+	// the bombs of a wave are logged right after the wave event
+	inWave := false
+	for _, e := range events {
+		if e.Type != telemetry.TypeSpawn {
+			inWave = false
+		}
+
+		switch e.Type {
+		case telemetry.TypeWave:
+			lastWave = e.Time
+			inWave = true
+
+		case telemetry.TypeSpawn:
+			if inWave && e.Position != nil {
+				r.SpawnMap.Add(e.Position[0], e.Position[1])
+			}
+
+		case telemetry.TypeNearMiss:
+			if e.Position != nil {
+				r.NearMissMap.Add(e.Position[0], e.Position[1])
+			}
+
+		case telemetry.TypeDeath:
+			d := Death{
+				Run:       name,
+				Distance:  e.Distance,
+				Time:      e.Time,
+				Cause:     e.Entity,
+				Component: e.Component,
+				SinceWave: -1.0,
+			}
+			if e.Position != nil {
+				d.X = e.Position[0]
+				d.Y = e.Position[1]
+			}
+			if lastWave >= 0.0 {
+				d.SinceWave = e.Time - lastWave
+			}
+			r.Deaths = append(r.Deaths, d)
+			r.DeathMap.Add(d.X, d.Y)
+		}
+	}
+}
+
+// bucketCount is a row of a bucketed table.
+type bucketCount struct {
+	label string
+	key   float64
+	count int
+}
+
+// countBuckets counts the deaths in buckets of the size using the value function.
+func (r *Report) countBuckets(size float64, unit string, value func(d *Death) (float64, bool)) []bucketCount {
+	counts := make(map[float64]int)
+	for i := range r.Deaths {
+		v, okay := value(&r.Deaths[i])
+		if !okay {
+			continue
+		}
+		counts[math.Floor(v/size)*size]++
+	}
+
+	rows := make([]bucketCount, 0, len(counts))
+	for key, count := range counts {
+		label := fmt.Sprintf("%g-%g%s", key, key+size, unit)
+		rows = append(rows, bucketCount{label: label, key: key, count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	return rows
+}
+
+// WriteTables writes the text tables of the report.
+func (r *Report) WriteTables(w io.Writer) {
+	fmt.Fprintf(w, "%d runs, %d deaths\n", r.Runs, len(r.Deaths))
+	if len(r.Deaths) == 0 {
+		return
+	}
+
+	writeTable := func(title string, rows []bucketCount) {
+		fmt.Fprintf(w, "\n%s\n", title)
+		for _, row := range rows {
+			fmt.Fprintf(w, "  %-20s %6d  %5.1f%%\n", row.label, row.count, 100.0*float64(row.count)/float64(len(r.Deaths)))
+		}
+	}
+
+	// deaths by the type of entity that caused them
+	byComponent := make(map[string]int)
+	for _, d := range r.Deaths {
+		component := d.Component
+		if component == "" {
+			component = "unknown"
+		}
+		byComponent[component]++
+	}
+	var componentRows []bucketCount
+	for component, count := range byComponent {
+		componentRows = append(componentRows, bucketCount{label: component, count: count})
+	}
+	sort.Slice(componentRows, func(i, j int) bool { return componentRows[i].count > componentRows[j].count })
+	writeTable("Deaths by cause", componentRows)
+
+	writeTable("Deaths by distance", r.countBuckets(r.Options.DistanceBucket, "m", func(d *Death) (float64, bool) {
+		return d.Distance, true
+	}))
+
+	writeTable("Deaths by time since the last spawn wave", r.countBuckets(r.Options.WaveBucket, "s", func(d *Death) (float64, bool) {
+		return d.SinceWave, d.SinceWave >= 0.0
+	}))
+
+	fmt.Fprintf(w, "\nDeaths across the tunnel cross-section (%gm cells, top row is the ceiling)\n", r.Options.CellSize)
+	r.DeathMap.WriteTable(w)
+	if r.DeathMap.Clamped > 0 {
+		fmt.Fprintf(w, "  %d deaths were outside the cross-section and counted in the edge cells\n", r.DeathMap.Clamped)
+	}
+
+	fmt.Fprintf(w, "\nHotspots: cells with the most deaths per bomb spawned there\n")
+	for _, h := range r.Hotspots(5) {
+		fmt.Fprintf(w, "  x %5.1f..%5.1f  y %5.1f..%5.1f  %4d deaths  %6d spawns  %.3f\n",
+			h.MinX, h.MinX+r.Options.CellSize, h.MinY, h.MinY+r.Options.CellSize, h.Deaths, h.Spawns, h.Ratio)
+	}
+}
+
+// Hotspot is a heatmap cell where players die disproportionately often.
+type Hotspot struct {
+	MinX, MinY float32
+	Deaths     int
+	Spawns     int
+	Ratio      float64
+}
+
+// Hotspots returns up to count cells with the highest ratio of deaths to bombs
+// spawned in the cell.
+func (r *Report) Hotspots(count int) []Hotspot {
+	var spots []Hotspot
+	for row := 0; row < r.DeathMap.Rows; row++ {
+		for col := 0; col < r.DeathMap.Cols; col++ {
+			deaths := r.DeathMap.At(col, row)
+			if deaths == 0 {
+				continue
+			}
+			spawns := r.SpawnMap.At(col, row)
+			minX, minY := r.DeathMap.CellMin(col, row)
+			spots = append(spots, Hotspot{
+				MinX:   minX,
+				MinY:   minY,
+				Deaths: deaths,
+				Spawns: spawns,
+				Ratio:  float64(deaths) / float64(spawns+1),
+			})
+		}
+	}
+	sort.Slice(spots, func(i, j int) bool { return spots[i].Ratio > spots[j].Ratio })
+	if len(spots) > count {
+		spots = spots[:count]
+	}
+	return spots
+}
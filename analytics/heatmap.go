@@ -0,0 +1,183 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package analytics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// heatmapCellPixels is the size of a heatmap cell in the PNG images.
+const heatmapCellPixels = 16
+
+// Grid counts points in cells across the tunnel cross-section.
+type Grid struct {
+	Options Options
+
+	Cols, Rows int
+	Cells      []int
+
+	// Clamped is the number of points outside the bounds that were counted
+	// in the nearest edge cell.
+	Clamped int
+}
+
+// NewGrid returns a new empty grid covering the bounds in the options.
+func NewGrid(opts Options) *Grid {
+	g := new(Grid)
+	g.Options = opts
+	g.Cols = int(math.Ceil(float64((opts.MaxX - opts.MinX) / opts.CellSize)))
+	g.Rows = int(math.Ceil(float64((opts.MaxY - opts.MinY) / opts.CellSize)))
+	g.Cells = make([]int, g.Cols*g.Rows)
+	return g
+}
+
+// Add counts the point in the cell it falls in.
+func (g *Grid) Add(x, y float32) {
+	col := int(math.Floor(float64((x - g.Options.MinX) / g.Options.CellSize)))
+	row := int(math.Floor(float64((y - g.Options.MinY) / g.Options.CellSize)))
+	if col < 0 || col >= g.Cols || row < 0 || row >= g.Rows {
+		g.Clamped++
+		col = clampInt(col, 0, g.Cols-1)
+		row = clampInt(row, 0, g.Rows-1)
+	}
+	g.Cells[row*g.Cols+col]++
+}
+
+// At returns the count for the cell.
+func (g *Grid) At(col, row int) int {
+	return g.Cells[row*g.Cols+col]
+}
+
+// CellMin returns the minimum X and Y coordinates covered by the cell.
+func (g *Grid) CellMin(col, row int) (float32, float32) {
+	return g.Options.MinX + float32(col)*g.Options.CellSize, g.Options.MinY + float32(row)*g.Options.CellSize
+}
+
+// Max returns the highest count in the grid.
+func (g *Grid) Max() int {
+	max := 0
+	for _, c := range g.Cells {
+		if c > max {
+			max = c
+		}
+	}
+	return max
+}
+
+// WriteTable writes the counts as a text table with the top row first.
+func (g *Grid) WriteTable(w io.Writer) {
+	for row := g.Rows - 1; row >= 0; row-- {
+		_, minY := g.CellMin(0, row)
+		fmt.Fprintf(w, "  %5.1f |", minY)
+		for col := 0; col < g.Cols; col++ {
+			fmt.Fprintf(w, "%4d", g.At(col, row))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "        +")
+	for col := 0; col < g.Cols; col++ {
+		fmt.Fprintf(w, "----")
+	}
+	fmt.Fprintf(w, "\n   x:   ")
+	for col := 0; col < g.Cols; col++ {
+		minX, _ := g.CellMin(col, 0)
+		fmt.Fprintf(w, "%4.0f", minX)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// Image renders the grid as a heatmap with the ceiling of the tunnel at the top.
+func (g *Grid) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, g.Cols*heatmapCellPixels, g.Rows*heatmapCellPixels))
+	max := g.Max()
+	for row := 0; row < g.Rows; row++ {
+		for col := 0; col < g.Cols; col++ {
+			var heat float64
+			if max > 0 {
+				heat = float64(g.At(col, row)) / float64(max)
+			}
+			c := heatColor(heat)
+
+			top := (g.Rows - 1 - row) * heatmapCellPixels
+			left := col * heatmapCellPixels
+			for y := top; y < top+heatmapCellPixels; y++ {
+				for x := left; x < left+heatmapCellPixels; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the heatmap to a PNG file.
+func (g *Grid) WritePNG(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, g.Image())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// heatColor maps heat from 0 to 1 onto black, blue, red, yellow and white.
+func heatColor(heat float64) color.RGBA {
+	stops := []color.RGBA{
+		{0, 0, 0, 255},
+		{0, 0, 255, 255},
+		{255, 0, 0, 255},
+		{255, 255, 0, 255},
+		{255, 255, 255, 255},
+	}
+	scaled := math.Max(0.0, math.Min(1.0, heat)) * float64(len(stops)-1)
+	i := int(scaled)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	t := scaled - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	} else if v > max {
+		return max
+	}
+	return v
+}
+
+// WriteHeatmaps writes the death, bomb spawn and near miss heatmaps of the
+// report to PNG files in the directory.
+func (r *Report) WriteHeatmaps(dir string) error {
+	maps := []struct {
+		name string
+		grid *Grid
+	}{
+		{"deaths.png", r.DeathMap},
+		{"spawns.png", r.SpawnMap},
+		{"near_misses.png", r.NearMissMap},
+	}
+	for _, m := range maps {
+		err := m.grid.WritePNG(filepath.Join(dir, m.name))
+		if err != nil {
+			return fmt.Errorf("failed to write the %s heatmap: %v", m.name, err)
+		}
+	}
+	return nil
+}
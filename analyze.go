@@ -0,0 +1,86 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tbogdala/infinigrid/analytics"
+	"github.com/tbogdala/infinigrid/game"
+	"github.com/tbogdala/infinigrid/replay"
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+const (
+	// tunnelHeight is the height of the inside of the tunnel.
+	tunnelHeight = 15.0
+
+	analyzeCellSize       = 1.0
+	analyzeDistanceBucket = 250.0
+	analyzeWaveBucket     = 0.25
+)
+
+// analyzeRuns aggregates the run logs (*.jsonl) and replays (*.igr) in the
+// directory, printing the text tables and writing the heatmaps to outDir.
+// It returns the exit code for the process.
+func analyzeRuns(dir string, outDir string) int {
+	report := analytics.NewReport(analytics.Options{
+		MinX:           -game.FloorSizeWidth / 2.0,
+		MaxX:           game.FloorSizeWidth / 2.0,
+		MinY:           0.0,
+		MaxY:           tunnelHeight,
+		CellSize:       analyzeCellSize,
+		DistanceBucket: analyzeDistanceBucket,
+		WaveBucket:     analyzeWaveBucket,
+	})
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		var events []telemetry.Event
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl":
+			events, err = telemetry.ReadFile(path)
+		case ".igr":
+			var rep *replay.Replay
+			rep, err = replay.LoadFile(path)
+			if err == nil {
+				events, err = game.ReplayEvents(rep)
+			}
+		default:
+			return nil
+		}
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", path, err)
+			return nil
+		}
+
+		report.AddRun(path, events)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Failed to read the runs. %v\n", err)
+		return 1
+	}
+
+	report.WriteTables(os.Stdout)
+
+	if outDir != "" {
+		err = os.MkdirAll(outDir, 0755)
+		if err == nil {
+			err = report.WriteHeatmaps(outDir)
+		}
+		if err != nil {
+			fmt.Printf("Failed to write the heatmaps. %v\n", err)
+			return 1
+		}
+		fmt.Printf("\nWrote the heatmaps to %s\n", outDir)
+	}
+	return 0
+}
@@ -0,0 +1,395 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package assetbuild converts the source models in assets-src to the binary
+// gombz models the game loads and keeps the meshes of the component files in
+// sync with them.
+//
+// Each distinct SrcFile referenced by the meshes of a component is an OBJ
+// file. An OBJ with a single object replaces the model of the mesh that
+// references it. An OBJ with several objects gets a mesh per object, matched
+// to the existing meshes by name so that their hand-authored materials and
+// transforms are kept; new objects copy the material of the first mesh
+// using the file. Everything else in the component, such as the
+// collisions, is left alone.
+//
+// The content hashes of the sources and outputs are kept in a cache file so
+// that unchanged sources are skipped.
+package assetbuild
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tbogdala/fizzle/component"
+)
+
+// defaultModelsDir is where new models go, relative to the component file,
+// when no mesh using the source file has a BinFile yet.
+const defaultModelsDir = "../models"
+
+// lfsPointerHeader is how a git-lfs pointer file starts.
+var lfsPointerHeader = []byte("version https://git-lfs")
+
+// Options control a build.
+type Options struct {
+	// ComponentsDir is the directory with the component JSON files.
+	ComponentsDir string
+
+	// CacheFile stores the content hashes from the last build. No cache is
+	// kept if it's empty.
+	CacheFile string
+
+	// Force rebuilds every model even if its source hasn't changed.
+	Force bool
+
+	// Log gets a line for each model built or skipped, if set.
+	Log io.Writer
+}
+
+// Result lists what a build did.
+type Result struct {
+	Built             []string
+	Skipped           []string
+	UpdatedComponents []string
+	Errors            []error
+}
+
+// CacheEntry is what was built from a source file.
+type CacheEntry struct {
+	SourceHash string
+
+	// Objects are the names of the objects in the source in file order.
+	Objects []string
+
+	// Outputs maps the models written to their content hashes.
+	Outputs map[string]string
+}
+
+// Cache maps the source files to what was last built from them.
+type Cache map[string]*CacheEntry
+
+// LoadCache reads the cache file. A missing file is an empty cache.
+func LoadCache(filename string) (Cache, error) {
+	cache := make(Cache)
+	if filename == "" {
+		return cache, nil
+	}
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(jsonBytes, &cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the build cache %s: %v", filename, err)
+	}
+	return cache, nil
+}
+
+// Save writes the cache file.
+func (c Cache) Save(filename string) error {
+	if filename == "" {
+		return nil
+	}
+	jsonBytes, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, jsonBytes, 0644)
+}
+
+// hashFile returns the hex encoded SHA-256 of the file's contents.
+func hashFile(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// upToDate returns true if the source and all of the outputs still have
+// the hashes from the cache entry.
+func (e *CacheEntry) upToDate(sourceHash string) bool {
+	if e == nil || e.SourceHash != sourceHash {
+		return false
+	}
+	for output, outputHash := range e.Outputs {
+		hash, err := hashFile(output)
+		if err != nil || hash != outputHash {
+			return false
+		}
+	}
+	return true
+}
+
+// builder holds the state of a build.
+type builder struct {
+	opts   Options
+	cache  Cache
+	result *Result
+}
+
+func (b *builder) logf(format string, a ...interface{}) {
+	if b.opts.Log != nil {
+		fmt.Fprintf(b.opts.Log, format+"\n", a...)
+	}
+}
+
+// Build converts the sources of all of the components in the directory and
+// updates their meshes. Problems with a component are collected in the
+// result and the rest of the components are still built; the returned
+// error is only for problems with the build as a whole.
+func Build(opts Options) (*Result, error) {
+	cache, err := LoadCache(opts.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(opts.ComponentsDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	b := &builder{opts: opts, cache: cache, result: new(Result)}
+	for _, filename := range files {
+		err := b.buildComponent(filename)
+		if err != nil {
+			b.result.Errors = append(b.result.Errors, fmt.Errorf("%s: %v", filename, err))
+		}
+	}
+
+	err = cache.Save(opts.CacheFile)
+	return b.result, err
+}
+
+// buildComponent builds the sources of the component and rewrites the
+// component file if its meshes changed.
+func (b *builder) buildComponent(filename string) error {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	c := new(component.Component)
+	err = json.Unmarshal(jsonBytes, c)
+	if err != nil {
+		return err
+	}
+
+	// group the meshes by their source file, keeping the order they're in
+	componentDir := filepath.Dir(filename)
+	var sources []string
+	groups := make(map[string][]*component.Mesh)
+	for _, compMesh := range c.Meshes {
+		if compMesh.SrcFile == "" {
+			continue
+		}
+		if _, okay := groups[compMesh.SrcFile]; !okay {
+			sources = append(sources, compMesh.SrcFile)
+		}
+		groups[compMesh.SrcFile] = append(groups[compMesh.SrcFile], compMesh)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	built := make(map[string][]*component.Mesh)
+	for _, src := range sources {
+		meshes, err := b.buildSource(componentDir, src, groups[src])
+		if err != nil {
+			return err
+		}
+		built[src] = meshes
+	}
+
+	// put the new meshes where the first mesh of their source was
+	var meshes []*component.Mesh
+	for _, compMesh := range c.Meshes {
+		if compMesh.SrcFile == "" {
+			meshes = append(meshes, compMesh)
+		} else if newMeshes, okay := built[compMesh.SrcFile]; okay {
+			meshes = append(meshes, newMeshes...)
+			delete(built, compMesh.SrcFile)
+		}
+	}
+	c.Meshes = meshes
+
+	newBytes, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(bytes.TrimSpace(jsonBytes), bytes.TrimSpace(newBytes)) {
+		return nil
+	}
+	err = ioutil.WriteFile(filename, newBytes, 0644)
+	if err != nil {
+		return err
+	}
+	b.result.UpdatedComponents = append(b.result.UpdatedComponents, filename)
+	b.logf("updated %s", filename)
+	return nil
+}
+
+// buildSource converts the source file if it changed and returns the mesh
+// entries for its objects.
+func (b *builder) buildSource(componentDir string, src string, existing []*component.Mesh) ([]*component.Mesh, error) {
+	srcPath := filepath.Join(componentDir, filepath.FromSlash(src))
+	cacheKey := filepath.ToSlash(srcPath)
+
+	sourceHash, err := hashFile(srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := b.cache[cacheKey]
+	if !b.opts.Force && entry.upToDate(sourceHash) {
+		meshes := meshEntries(existing, entry.Objects)
+		if outputsMatch(componentDir, meshes, entry) {
+			b.result.Skipped = append(b.result.Skipped, srcPath)
+			b.logf("unchanged %s", srcPath)
+			return meshes, nil
+		}
+	}
+
+	isPointer, err := isLFSPointer(srcPath)
+	if err != nil {
+		return nil, err
+	} else if isPointer {
+		return nil, fmt.Errorf("%s is a git-lfs pointer; run 'git lfs pull' to fetch it", srcPath)
+	}
+
+	objects, err := LoadOBJ(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", srcPath, err)
+	}
+	names := make([]string, len(objects))
+	for i, obj := range objects {
+		names[i] = obj.Name
+	}
+
+	entry = &CacheEntry{SourceHash: sourceHash, Objects: names, Outputs: make(map[string]string)}
+	meshes := meshEntries(existing, names)
+	for i, obj := range objects {
+		output := filepath.Join(componentDir, filepath.FromSlash(meshes[i].BinFile))
+		meshBytes, err := obj.Mesh.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %v", output, err)
+		}
+		err = ioutil.WriteFile(output, meshBytes, 0644)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(meshBytes)
+		entry.Outputs[output] = hex.EncodeToString(sum[:])
+		b.result.Built = append(b.result.Built, output)
+		b.logf("built %s from %s (%d vertices, %d faces)", output, srcPath, obj.Mesh.VertexCount, obj.Mesh.FaceCount)
+	}
+	b.cache[cacheKey] = entry
+	return meshes, nil
+}
+
+// outputsMatch returns true if the models for the meshes are the ones in
+// the cache entry, which won't be the case if the BinFiles were changed.
+func outputsMatch(componentDir string, meshes []*component.Mesh, entry *CacheEntry) bool {
+	if len(meshes) != len(entry.Outputs) {
+		return false
+	}
+	for _, compMesh := range meshes {
+		output := filepath.Join(componentDir, filepath.FromSlash(compMesh.BinFile))
+		if _, okay := entry.Outputs[output]; !okay {
+			return false
+		}
+	}
+	return true
+}
+
+// meshEntries returns the mesh entries for the objects of a source file
+// given the meshes that used the file before.
+func meshEntries(existing []*component.Mesh, objects []string) []*component.Mesh {
+	// a single object is simply the new model for a single mesh
+	if len(objects) == 1 && len(existing) == 1 {
+		m := *existing[0]
+		if m.BinFile == "" {
+			m.BinFile = modelPath(existing, "")
+		}
+		return []*component.Mesh{&m}
+	}
+
+	byName := make(map[string]*component.Mesh)
+	for _, compMesh := range existing {
+		byName[compMesh.Name] = compMesh
+	}
+
+	meshes := make([]*component.Mesh, len(objects))
+	for i, name := range objects {
+		var m component.Mesh
+		if old, okay := byName[name]; okay {
+			m = *old
+		} else {
+			m = *existing[0]
+			m.BinFile = ""
+			if name != "" {
+				m.Name = name
+			}
+		}
+		if m.BinFile == "" {
+			m.BinFile = modelPath(existing, name)
+		}
+		meshes[i] = &m
+	}
+	return meshes
+}
+
+// modelPath returns the BinFile for a new model built for the object, next
+// to the other models of the source file.
+func modelPath(existing []*component.Mesh, object string) string {
+	dir := defaultModelsDir
+	for _, compMesh := range existing {
+		if compMesh.BinFile != "" {
+			dir = path.Dir(filepath.ToSlash(compMesh.BinFile))
+			break
+		}
+	}
+
+	base := strings.TrimSuffix(path.Base(filepath.ToSlash(existing[0].SrcFile)), path.Ext(existing[0].SrcFile))
+	if object != "" {
+		base += "." + strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ' ' {
+				return '_'
+			}
+			return r
+		}, object)
+	}
+	return path.Join(dir, base+".gombz")
+}
+
+// isLFSPointer returns true if the file is a git-lfs pointer.
+func isLFSPointer(filename string) (bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(lfsPointerHeader))
+	n, _ := io.ReadFull(f, header)
+	return bytes.Equal(header[:n], lfsPointerHeader), nil
+}
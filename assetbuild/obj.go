@@ -0,0 +1,280 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package assetbuild
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/gombz"
+)
+
+// ObjObject is a named object from an OBJ file as an indexed triangle mesh.
+type ObjObject struct {
+	Name string
+	Mesh *gombz.Mesh
+}
+
+// objIndex is a vertex of a face referencing the position, texture
+// coordinate and normal lists of the OBJ file. Missing ones are -1.
+type objIndex struct {
+	v, vt, vn int
+}
+
+// objBuilder turns the faces of an object into an indexed mesh, creating a
+// mesh vertex for each distinct combination of indexes.
+type objBuilder struct {
+	name    string
+	indexes map[objIndex]uint32
+	verts   []objIndex
+	faces   []gombz.MeshFace
+}
+
+func newObjBuilder(name string) *objBuilder {
+	b := new(objBuilder)
+	b.name = name
+	b.indexes = make(map[objIndex]uint32)
+	return b
+}
+
+func (b *objBuilder) vertex(idx objIndex) uint32 {
+	if i, okay := b.indexes[idx]; okay {
+		return i
+	}
+	i := uint32(len(b.verts))
+	b.indexes[idx] = i
+	b.verts = append(b.verts, idx)
+	return i
+}
+
+// ParseOBJ reads the objects from Wavefront OBJ data. Polygons are split
+// into triangle fans, missing normals are generated from the faces and
+// tangents are generated when there are texture coordinates. Data before
+// the first 'o' statement goes in an object with an empty name.
+func ParseOBJ(r io.Reader) ([]*ObjObject, error) {
+	var positions, normals []mgl.Vec3
+	var uvs []mgl.Vec2
+	var builders []*objBuilder
+	var current *objBuilder
+
+	// resolve turns a 1-based or negative relative OBJ index into a 0-based one.
+	resolve := func(s string, count int) (int, error) {
+		if s == "" {
+			return -1, nil
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			i = count + i
+		} else {
+			i--
+		}
+		if i < 0 || i >= count {
+			return 0, fmt.Errorf("index %s is out of range", s)
+		}
+		return i, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "v", "vn":
+			var v mgl.Vec3
+			v, err = parseFloats3(fields[1:])
+			if fields[0] == "v" {
+				positions = append(positions, v)
+			} else {
+				normals = append(normals, v)
+			}
+		case "vt":
+			var v mgl.Vec3
+			v, err = parseFloats3(append(fields[1:], "0", "0")[:2])
+			uvs = append(uvs, mgl.Vec2{v[0], v[1]})
+		case "o":
+			current = newObjBuilder(strings.Join(fields[1:], " "))
+			builders = append(builders, current)
+		case "f":
+			if len(fields) < 4 {
+				err = fmt.Errorf("a face needs at least three vertices")
+				break
+			}
+			if current == nil {
+				current = newObjBuilder("")
+				builders = append(builders, current)
+			}
+			face := make([]uint32, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				parts := strings.Split(field, "/")
+				var idx objIndex
+				idx.v, err = resolve(parts[0], len(positions))
+				if err == nil && idx.v < 0 {
+					err = fmt.Errorf("a face vertex needs a position")
+				}
+				idx.vt, idx.vn = -1, -1
+				if err == nil && len(parts) > 1 {
+					idx.vt, err = resolve(parts[1], len(uvs))
+				}
+				if err == nil && len(parts) > 2 {
+					idx.vn, err = resolve(parts[2], len(normals))
+				}
+				if err != nil {
+					break
+				}
+				face = append(face, current.vertex(idx))
+			}
+			for i := 2; err == nil && i < len(face); i++ {
+				current.faces = append(current.faces, gombz.MeshFace{face[0], face[i-1], face[i]})
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var objects []*ObjObject
+	for _, b := range builders {
+		if len(b.faces) == 0 {
+			continue
+		}
+		objects = append(objects, &ObjObject{Name: b.name, Mesh: b.mesh(positions, uvs, normals)})
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no faces found")
+	}
+	return objects, nil
+}
+
+// LoadOBJ reads the objects from the Wavefront OBJ file.
+func LoadOBJ(filename string) ([]*ObjObject, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseOBJ(f)
+}
+
+func parseFloats3(fields []string) (mgl.Vec3, error) {
+	var v mgl.Vec3
+	if len(fields) < 2 {
+		return v, fmt.Errorf("expected at least two numbers")
+	}
+	for i := 0; i < 3 && i < len(fields); i++ {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// mesh creates the gombz mesh for the object.
+func (b *objBuilder) mesh(positions []mgl.Vec3, uvs []mgl.Vec2, normals []mgl.Vec3) *gombz.Mesh {
+	m := new(gombz.Mesh)
+	m.VertexCount = uint32(len(b.verts))
+	m.FaceCount = uint32(len(b.faces))
+	m.Faces = b.faces
+	m.Vertices = make([]mgl.Vec3, len(b.verts))
+	m.Normals = make([]mgl.Vec3, len(b.verts))
+
+	hasUVs := false
+	hasNormals := true
+	for _, idx := range b.verts {
+		hasUVs = hasUVs || idx.vt >= 0
+		hasNormals = hasNormals && idx.vn >= 0
+	}
+
+	var texCoords []mgl.Vec2
+	if hasUVs {
+		texCoords = make([]mgl.Vec2, len(b.verts))
+	}
+	for i, idx := range b.verts {
+		m.Vertices[i] = positions[idx.v]
+		if idx.vn >= 0 {
+			m.Normals[i] = normals[idx.vn]
+		}
+		if hasUVs && idx.vt >= 0 {
+			texCoords[i] = uvs[idx.vt]
+		}
+	}
+
+	if !hasNormals {
+		generateNormals(m)
+	}
+	if hasUVs {
+		m.UVChannelCount = 1
+		m.UVChannels = [][]mgl.Vec2{texCoords}
+		m.Tangents = generateTangents(m.Vertices, m.Normals, texCoords, m.Faces)
+	}
+	return m
+}
+
+// generateNormals sets the normals of the mesh to the area weighted average
+// of the normals of the faces using each vertex.
+func generateNormals(m *gombz.Mesh) {
+	for i := range m.Normals {
+		m.Normals[i] = mgl.Vec3{}
+	}
+	for _, f := range m.Faces {
+		a, b, c := m.Vertices[f[0]], m.Vertices[f[1]], m.Vertices[f[2]]
+		n := b.Sub(a).Cross(c.Sub(a))
+		for _, i := range f {
+			m.Normals[i] = m.Normals[i].Add(n)
+		}
+	}
+	for i, n := range m.Normals {
+		if n.Len() > 0.0 {
+			m.Normals[i] = n.Normalize()
+		}
+	}
+}
+
+// generateTangents returns the tangents for each vertex from the texture
+// coordinates, orthogonalized against the normals.
+func generateTangents(verts []mgl.Vec3, normals []mgl.Vec3, uvs []mgl.Vec2, faces []gombz.MeshFace) []mgl.Vec3 {
+	tangents := make([]mgl.Vec3, len(verts))
+	for _, f := range faces {
+		e1 := verts[f[1]].Sub(verts[f[0]])
+		e2 := verts[f[2]].Sub(verts[f[0]])
+		d1 := uvs[f[1]].Sub(uvs[f[0]])
+		d2 := uvs[f[2]].Sub(uvs[f[0]])
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+		if det == 0.0 {
+			continue
+		}
+		t := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(1.0 / det)
+		for _, i := range f {
+			tangents[i] = tangents[i].Add(t)
+		}
+	}
+	for i, t := range tangents {
+		n := normals[i]
+		t = t.Sub(n.Mul(n.Dot(t)))
+		if t.Len() > 0.0 {
+			tangents[i] = t.Normalize()
+		} else {
+			tangents[i] = mgl.Vec3{}
+		}
+	}
+	return tangents
+}
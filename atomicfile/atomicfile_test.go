@@ -0,0 +1,41 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatalf("failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "data.json")
+	for _, data := range []string{"first", "second"} {
+		err = WriteFile(filename, []byte(data))
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", data, err)
+		}
+		written, err := ioutil.ReadFile(filename)
+		if err != nil || string(written) != data {
+			t.Errorf("expected the file to hold %q, got %q with error %v", data, written, err)
+		}
+	}
+
+	// a failed write leaves no temporary files behind
+	err = WriteFile(filepath.Join(dir, "missing", "data.json"), []byte("lost"))
+	if err == nil {
+		t.Errorf("expected writing into a missing directory to fail")
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("expected only the written file in the directory, got %d files", len(files))
+	}
+}
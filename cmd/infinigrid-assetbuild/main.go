@@ -0,0 +1,45 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Command infinigrid-assetbuild converts the OBJ models in assets-src to the
+// gombz models in assets and updates the meshes of the component files to
+// match. Sources that haven't changed since the last build are skipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tbogdala/infinigrid/assetbuild"
+)
+
+var (
+	flagComponents = flag.String("components", "assets/components", "the directory with the component files to build")
+	flagCache      = flag.String("cache", "assets-src/buildcache.json", "the file that stores the content hashes of the last build")
+	flagForce      = flag.Bool("force", false, "rebuilds every model even if its source hasn't changed")
+)
+
+func main() {
+	flag.Parse()
+
+	result, err := assetbuild.Build(assetbuild.Options{
+		ComponentsDir: *flagComponents,
+		CacheFile:     *flagCache,
+		Force:         *flagForce,
+		Log:           os.Stdout,
+	})
+	if err != nil {
+		fmt.Printf("The build failed. %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, err := range result.Errors {
+		fmt.Printf("ERROR %v\n", err)
+	}
+	fmt.Printf("Built %d model(s), skipped %d unchanged and updated %d component(s).\n",
+		len(result.Built), len(result.Skipped), len(result.UpdatedComponents))
+	if len(result.Errors) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Command infinigrid-colliderfit proposes colliders for component files by
+// fitting them to the meshes and reports how well they cover the meshes.
+// With -write the colliders replace the Collisions of the components.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tbogdala/infinigrid/colliderfit"
+	"github.com/tbogdala/infinigrid/trimesh"
+)
+
+var (
+	flagMethod     = flag.String("method", string(colliderfit.DefaultOptions.Method), "the colliders to fit: sphere, aabb or decompose")
+	flagTolerance  = flag.Float64("tolerance", colliderfit.DefaultOptions.Tolerance, "the fraction of a box that can be empty before decompose splits it")
+	flagMaxBoxes   = flag.Int("maxboxes", colliderfit.DefaultOptions.MaxBoxes, "the most boxes decompose will create")
+	flagResolution = flag.Int("resolution", colliderfit.DefaultOptions.Resolution, "the voxels along the longest side of the mesh used to measure coverage")
+	flagWrite      = flag.Bool("write", false, "writes the fitted colliders into the component files")
+	flagMixedTags  = flag.Bool("mixedtags", false, "writes the fitted colliders even if the current ones have different tags, giving them all the tags of the first")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] component.json...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := colliderfit.Options{
+		Method:     colliderfit.Method(*flagMethod),
+		Tolerance:  *flagTolerance,
+		MaxBoxes:   *flagMaxBoxes,
+		Resolution: *flagResolution,
+	}
+
+	failed := false
+	for _, filename := range flag.Args() {
+		err := fitComponent(filename, opts)
+		if err != nil {
+			fmt.Printf("%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fitComponent fits the colliders for the component and prints a report
+// comparing them with the current ones.
+func fitComponent(filename string, opts colliderfit.Options) error {
+	c, tris, err := trimesh.LoadComponent(filename)
+	if err != nil {
+		return err
+	}
+	result, err := colliderfit.Fit(tris, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%d triangles):\n", filename, len(tris))
+	if len(c.Collisions) > 0 {
+		current := colliderfit.MeasureCoverage(tris, c.Collisions, opts.Resolution)
+		fmt.Printf("  current %d collider(s): %v\n", len(c.Collisions), current)
+	}
+	fmt.Printf("  fitted %d collider(s) with %s: %v\n", len(result.Colliders), opts.Method, result.Coverage)
+	for _, col := range result.Colliders {
+		colBytes, _ := json.Marshal(col)
+		fmt.Printf("    %s\n", colBytes)
+	}
+
+	if !*flagWrite {
+		return nil
+	}
+	err = result.Apply(c, *flagMixedTags)
+	if err != nil {
+		return fmt.Errorf("%v; use -mixedtags to give the fitted colliders the tags of the first", err)
+	}
+	jsonBytes, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filename, jsonBytes, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  wrote the colliders to %s\n", filename)
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Command infinigrid-leaderboard is the reference leaderboard server for
+// Infinigrid. It stores the submitted runs and their replays in a directory.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/tbogdala/infinigrid/game"
+	"github.com/tbogdala/infinigrid/leaderboard"
+)
+
+var (
+	flagAddr    = flag.String("addr", ":8080", "the address to listen on")
+	flagDataDir = flag.String("data", "leaderboard-data", "the directory to store the runs and replays in")
+
+	flagVerify = flag.Bool("verify", false, "re-simulates the submitted runs from their replays and rejects the ones that don't match")
+	flagAssets = flag.String("assets", game.DefaultAssetRoot, "the game's asset directories, separated like PATH, used to verify the runs")
+
+	flagVerifyTimeout = flag.Duration("verifytimeout", game.DefaultVerifyTimeout, "how long a run may take to verify before it's refused")
+)
+
+func main() {
+	flag.Parse()
+
+	store, err := leaderboard.OpenFileStore(*flagDataDir)
+	if err != nil {
+		log.Fatalf("Failed to open the leaderboard store: %v", err)
+	}
+
+	server := leaderboard.NewServer(store)
+	if *flagVerify {
+		game.AssetRoots = filepath.SplitList(*flagAssets)
+		server.Verifier = &game.LeaderboardVerifier{Timeout: *flagVerifyTimeout}
+		log.Printf("Verifying runs with the assets in %s", strings.Join(game.AssetRoots, ", "))
+	}
+	log.Printf("Leaderboard listening on %s storing runs in %s", *flagAddr, *flagDataDir)
+	log.Fatal(http.ListenAndServe(*flagAddr, server))
+}
@@ -0,0 +1,378 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package colliderfit proposes colliders for a component by fitting them to
+// the geometry of its meshes.
+//
+// The mesh is voxelized to measure how well the colliders cover it: the
+// uncovered error is the fraction of the mesh outside of the colliders and
+// the excess error is the fraction of the colliders' volume that is empty
+// space the ship would still crash into.
+package colliderfit
+
+import (
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle/component"
+
+	"github.com/tbogdala/infinigrid/trimesh"
+)
+
+// Method is a way of fitting colliders to a mesh.
+type Method string
+
+// The fitting methods.
+const (
+	// MethodSphere fits a single bounding sphere.
+	MethodSphere Method = "sphere"
+
+	// MethodAABB fits a single tight axis aligned bounding box.
+	MethodAABB Method = "aabb"
+
+	// MethodDecompose splits the mesh into a small set of boxes, splitting
+	// further while a box has more empty space than the tolerance allows.
+	MethodDecompose Method = "decompose"
+)
+
+// Options control the fitting.
+type Options struct {
+	Method Method
+
+	// Tolerance is the fraction of a box that can be empty space before
+	// MethodDecompose splits it.
+	Tolerance float64
+
+	// MaxBoxes is the most boxes MethodDecompose will create.
+	MaxBoxes int
+
+	// Resolution is the number of voxels along the longest side of the mesh
+	// used to measure the coverage and decompose the mesh.
+	Resolution int
+}
+
+// DefaultOptions are reasonable options for the game's meshes.
+var DefaultOptions = Options{
+	Method:     MethodAABB,
+	Tolerance:  0.1,
+	MaxBoxes:   4,
+	Resolution: 32,
+}
+
+// Coverage is how well a set of colliders covers the mesh.
+type Coverage struct {
+	// MeshVolume and ColliderVolume are the measured volumes.
+	MeshVolume     float64
+	ColliderVolume float64
+
+	// Uncovered is the fraction of the mesh volume outside the colliders.
+	Uncovered float64
+
+	// Excess is the fraction of the collider volume outside the mesh.
+	Excess float64
+}
+
+// String returns the coverage as a line for a report.
+func (c Coverage) String() string {
+	return fmt.Sprintf("mesh volume %.4g, collider volume %.4g, uncovered %.1f%%, excess %.1f%%",
+		c.MeshVolume, c.ColliderVolume, c.Uncovered*100.0, c.Excess*100.0)
+}
+
+// Result is the colliders fitted to a mesh.
+type Result struct {
+	Colliders []*component.CollisionRef
+	Coverage  Coverage
+}
+
+// Fit fits colliders to the triangles.
+func Fit(tris []trimesh.Triangle, opts Options) (*Result, error) {
+	if len(tris) == 0 {
+		return nil, fmt.Errorf("there are no triangles to fit")
+	}
+	if opts.Resolution < 1 {
+		opts.Resolution = DefaultOptions.Resolution
+	}
+	if opts.MaxBoxes < 1 {
+		opts.MaxBoxes = 1
+	}
+
+	grid, err := newVoxelGrid(tris, opts.Resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(Result)
+	switch opts.Method {
+	case MethodSphere:
+		center, radius := boundingSphere(tris)
+		r.Colliders = []*component.CollisionRef{newSphere(center, radius)}
+	case MethodAABB:
+		min, max := trimesh.Bounds(tris)
+		min, max = padFlat(min, max, grid.cellSize*0.5)
+		r.Colliders = []*component.CollisionRef{newAABB(min, max)}
+	case MethodDecompose:
+		for _, b := range decompose(grid, opts) {
+			min, max := grid.worldBounds(b)
+			min, max = padFlat(min, max, grid.cellSize*0.5)
+			r.Colliders = append(r.Colliders, newAABB(min, max))
+		}
+	default:
+		return nil, fmt.Errorf("unknown fitting method %q", opts.Method)
+	}
+
+	r.Coverage = measureCoverage(grid, r.Colliders)
+	return r, nil
+}
+
+func newSphere(center mgl.Vec3, radius float32) *component.CollisionRef {
+	return &component.CollisionRef{Type: component.ColliderTypeSphere, Radius: radius, Offset: center}
+}
+
+func newAABB(min, max mgl.Vec3) *component.CollisionRef {
+	return &component.CollisionRef{Type: component.ColliderTypeAABB, Min: min, Max: max}
+}
+
+// padFlat gives the box a thickness on the axes it's flat on, such as for
+// a plane, since a box needs Min < Max on every axis.
+func padFlat(min, max mgl.Vec3, thickness float32) (mgl.Vec3, mgl.Vec3) {
+	for i := 0; i < 3; i++ {
+		if max[i]-min[i] < thickness {
+			middle := (min[i] + max[i]) * 0.5
+			min[i] = middle - thickness*0.5
+			max[i] = middle + thickness*0.5
+		}
+	}
+	return min, max
+}
+
+// boundingSphere returns a sphere containing all of the vertices using
+// Ritter's algorithm.
+func boundingSphere(tris []trimesh.Triangle) (mgl.Vec3, float32) {
+	farthest := func(from mgl.Vec3) mgl.Vec3 {
+		best := from
+		bestDist := float32(-1.0)
+		for _, t := range tris {
+			for _, v := range t {
+				if d := v.Sub(from).Len(); d > bestDist {
+					best, bestDist = v, d
+				}
+			}
+		}
+		return best
+	}
+
+	a := farthest(tris[0][0])
+	b := farthest(a)
+	center := a.Add(b).Mul(0.5)
+	radius := b.Sub(a).Len() * 0.5
+	for _, t := range tris {
+		for _, v := range t {
+			d := v.Sub(center).Len()
+			if d <= radius {
+				continue
+			}
+			newRadius := (radius + d) * 0.5
+			center = center.Add(v.Sub(center).Mul((newRadius - radius) / d))
+			radius = newRadius
+		}
+	}
+	return center, radius
+}
+
+// decompose splits the solid cells of the grid into boxes, always splitting
+// the box with the most empty space next, until every box is within the
+// tolerance or there are MaxBoxes of them.
+func decompose(grid *voxelGrid, opts Options) []cellBox {
+	all := cellBox{hi: [3]int{grid.dims[0] - 1, grid.dims[1] - 1, grid.dims[2] - 1}}
+	root, okay := grid.tighten(all)
+	if !okay {
+		return nil
+	}
+
+	boxes := []cellBox{root}
+	final := []bool{false}
+	for len(boxes) < opts.MaxBoxes {
+		worst := -1
+		worstEmpty := 0
+		for i, b := range boxes {
+			empty := b.volume() - grid.count(b)
+			if final[i] || float64(empty)/float64(b.volume()) <= opts.Tolerance {
+				continue
+			}
+			if worst < 0 || empty > worstEmpty {
+				worst, worstEmpty = i, empty
+			}
+		}
+		if worst < 0 {
+			break
+		}
+
+		a, b, okay := grid.split(boxes[worst])
+		if !okay {
+			final[worst] = true
+			continue
+		}
+		boxes[worst] = a
+		boxes = append(boxes, b)
+		final = append(final, false)
+	}
+	return boxes
+}
+
+// contains returns true if the point is inside the collider.
+func contains(c *component.CollisionRef, p mgl.Vec3) bool {
+	switch c.Type {
+	case component.ColliderTypeSphere:
+		return p.Sub(c.Offset).Len() <= c.Radius
+	case component.ColliderTypeAABB:
+		p = p.Sub(c.Offset)
+		for i := 0; i < 3; i++ {
+			if p[i] < c.Min[i] || p[i] > c.Max[i] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// colliderBounds returns the bounds of the collider.
+func colliderBounds(c *component.CollisionRef) (mgl.Vec3, mgl.Vec3) {
+	if c.Type == component.ColliderTypeSphere {
+		r := mgl.Vec3{c.Radius, c.Radius, c.Radius}
+		return c.Offset.Sub(r), c.Offset.Add(r)
+	}
+	return c.Min.Add(c.Offset), c.Max.Add(c.Offset)
+}
+
+// measureCoverage measures the coverage of the colliders by sampling the
+// cell centers of the mesh and a grid of the same spacing over the colliders.
+func measureCoverage(grid *voxelGrid, colliders []*component.CollisionRef) Coverage {
+	inAny := func(p mgl.Vec3) bool {
+		for _, c := range colliders {
+			if contains(c, p) {
+				return true
+			}
+		}
+		return false
+	}
+	cellVolume := math.Pow(float64(grid.cellSize), 3.0)
+	half := mgl.Vec3{grid.cellSize, grid.cellSize, grid.cellSize}.Mul(0.5)
+
+	var cov Coverage
+	solid, uncovered := 0, 0
+	for z := 0; z < grid.dims[2]; z++ {
+		for y := 0; y < grid.dims[1]; y++ {
+			for x := 0; x < grid.dims[0]; x++ {
+				if !grid.solid[grid.index(x, y, z)] {
+					continue
+				}
+				solid++
+				if !inAny(grid.cellMin(x, y, z).Add(half)) {
+					uncovered++
+				}
+			}
+		}
+	}
+
+	// sample the colliders aligned with the cells so the samples land on
+	// the same centers the mesh was measured with.
+	total, empty := 0, 0
+	if len(colliders) > 0 {
+		min, max := colliderBounds(colliders[0])
+		for _, c := range colliders[1:] {
+			cmin, cmax := colliderBounds(c)
+			for i := 0; i < 3; i++ {
+				min[i] = float32(math.Min(float64(min[i]), float64(cmin[i])))
+				max[i] = float32(math.Max(float64(max[i]), float64(cmax[i])))
+			}
+		}
+		var lo, hi [3]int
+		for i := 0; i < 3; i++ {
+			lo[i] = int(math.Floor(float64((min[i] - grid.origin[i]) / grid.cellSize)))
+			hi[i] = int(math.Ceil(float64((max[i] - grid.origin[i]) / grid.cellSize)))
+		}
+		for z := lo[2]; z <= hi[2]; z++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for x := lo[0]; x <= hi[0]; x++ {
+					p := grid.cellMin(x, y, z).Add(half)
+					if !inAny(p) {
+						continue
+					}
+					total++
+					if !grid.isSolid(p) {
+						empty++
+					}
+				}
+			}
+		}
+	}
+
+	cov.MeshVolume = float64(solid) * cellVolume
+	cov.ColliderVolume = float64(total) * cellVolume
+	if solid > 0 {
+		cov.Uncovered = float64(uncovered) / float64(solid)
+	}
+	if total > 0 {
+		cov.Excess = float64(empty) / float64(total)
+	}
+	return cov
+}
+
+// Apply replaces the colliders of the component with the fitted ones, each
+// with its own copy of the tags of the old colliders. If the old colliders
+// don't all have the same tags it's unclear which tags the new ones should
+// have, so the component is left unchanged and an error is returned unless
+// mixedTags is true, in which case the tags of the first are used.
+func (r *Result) Apply(c *component.Component, mixedTags bool) error {
+	var tags []string
+	if len(c.Collisions) > 0 {
+		tags = c.Collisions[0].Tags
+	}
+	if !mixedTags {
+		for i, col := range c.Collisions {
+			if !sameTags(col.Tags, tags) {
+				return fmt.Errorf("the current colliders have different tags (%v on the first, %v on collider %d)", tags, col.Tags, i)
+			}
+		}
+	}
+
+	collisions := make([]*component.CollisionRef, len(r.Colliders))
+	for i, col := range r.Colliders {
+		newCol := *col
+		newCol.Tags = nil
+		if len(tags) > 0 {
+			newCol.Tags = append([]string(nil), tags...)
+		}
+		collisions[i] = &newCol
+	}
+	c.Collisions = collisions
+	return nil
+}
+
+// sameTags returns true if the tags are the same and in the same order.
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MeasureCoverage measures how well existing colliders cover the triangles
+// so they can be compared with fitted ones.
+func MeasureCoverage(tris []trimesh.Triangle, colliders []*component.CollisionRef, resolution int) Coverage {
+	if len(tris) == 0 {
+		return Coverage{}
+	}
+	grid, err := newVoxelGrid(tris, resolution)
+	if err != nil {
+		return Coverage{}
+	}
+	return measureCoverage(grid, colliders)
+}
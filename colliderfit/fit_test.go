@@ -0,0 +1,79 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package colliderfit
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	component "github.com/tbogdala/fizzle/component"
+)
+
+// testResult returns a result of count boxes.
+func testResult(count int) *Result {
+	r := new(Result)
+	for i := 0; i < count; i++ {
+		r.Colliders = append(r.Colliders, &component.CollisionRef{
+			Type: component.ColliderTypeAABB,
+			Min:  mgl.Vec3{float32(i), 0.0, 0.0},
+			Max:  mgl.Vec3{float32(i) + 1.0, 1.0, 1.0},
+		})
+	}
+	return r
+}
+
+func TestApplyCopiesTags(t *testing.T) {
+	c := &component.Component{
+		Collisions: []*component.CollisionRef{
+			{Type: component.ColliderTypeSphere, Radius: 1.0, Tags: []string{"hazard", "bonus:10"}},
+			{Type: component.ColliderTypeSphere, Radius: 0.5, Tags: []string{"hazard", "bonus:10"}},
+		},
+	}
+	err := testResult(3).Apply(c, false)
+	if err != nil {
+		t.Fatalf("failed to apply the colliders: %v", err)
+	}
+	if len(c.Collisions) != 3 {
+		t.Fatalf("expected 3 colliders, got %d", len(c.Collisions))
+	}
+
+	// changing the tags of one collider leaves the others alone
+	c.Collisions[0].Tags[0] = "pickup"
+	for i, col := range c.Collisions[1:] {
+		if !sameTags(col.Tags, []string{"hazard", "bonus:10"}) {
+			t.Errorf("collider %d shares its tags: %v", i+1, col.Tags)
+		}
+	}
+}
+
+func TestApplyMixedTags(t *testing.T) {
+	newComponent := func() *component.Component {
+		return &component.Component{
+			Collisions: []*component.CollisionRef{
+				{Type: component.ColliderTypeSphere, Radius: 1.0, Tags: []string{"hazard"}},
+				{Type: component.ColliderTypeSphere, Radius: 0.5, Tags: []string{"trigger", "ring"}},
+			},
+		}
+	}
+
+	c := newComponent()
+	err := testResult(2).Apply(c, false)
+	if err == nil {
+		t.Errorf("expected an error for the colliders with different tags")
+	}
+	if c.Collisions[1].Radius != 0.5 || !sameTags(c.Collisions[1].Tags, []string{"trigger", "ring"}) {
+		t.Errorf("the colliders changed even though the tags were mixed")
+	}
+
+	c = newComponent()
+	err = testResult(2).Apply(c, true)
+	if err != nil {
+		t.Fatalf("failed to apply the colliders with mixedTags: %v", err)
+	}
+	for i, col := range c.Collisions {
+		if col.Type != component.ColliderTypeAABB || !sameTags(col.Tags, []string{"hazard"}) {
+			t.Errorf("collider %d is type %d with tags %v, expected a box tagged hazard", i, col.Type, col.Tags)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package colliderfit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/infinigrid/trimesh"
+)
+
+// voxelGrid is the volume of a mesh split into cubic cells. A cell is solid
+// if the surface of the mesh passes through it or it's inside the mesh.
+type voxelGrid struct {
+	origin   mgl.Vec3
+	cellSize float32
+	dims     [3]int
+	solid    []bool
+
+	// the bounds of the surface inside each cell, for cells with surface
+	surface    []bool
+	surfaceMin []mgl.Vec3
+	surfaceMax []mgl.Vec3
+
+	// sums is the summed volume table of the solid cells, with one extra
+	// row on each axis so that sums at index 0 are zero.
+	sums []int
+}
+
+// cellBox is a box of cells with inclusive bounds.
+type cellBox struct {
+	lo, hi [3]int
+}
+
+func (b cellBox) volume() int {
+	return (b.hi[0] - b.lo[0] + 1) * (b.hi[1] - b.lo[1] + 1) * (b.hi[2] - b.lo[2] + 1)
+}
+
+// newVoxelGrid voxelizes the triangles with resolution cells along the
+// longest side of their bounds.
+func newVoxelGrid(tris []trimesh.Triangle, resolution int) (*voxelGrid, error) {
+	min, max := trimesh.Bounds(tris)
+	size := max.Sub(min)
+	longest := math.Max(float64(size[0]), math.Max(float64(size[1]), float64(size[2])))
+	if longest <= 0.0 {
+		return nil, fmt.Errorf("the mesh has no size")
+	}
+
+	g := new(voxelGrid)
+	g.cellSize = float32(longest / float64(resolution))
+	for i := 0; i < 3; i++ {
+		g.dims[i] = int(math.Ceil(float64(size[i]/g.cellSize))) + 1
+	}
+	g.origin = min.Sub(mgl.Vec3{g.cellSize, g.cellSize, g.cellSize}.Mul(0.5))
+
+	count := g.dims[0] * g.dims[1] * g.dims[2]
+	g.solid = make([]bool, count)
+	g.surface = make([]bool, count)
+	g.surfaceMin = make([]mgl.Vec3, count)
+	g.surfaceMax = make([]mgl.Vec3, count)
+
+	for _, t := range tris {
+		g.addSurface(t)
+	}
+	g.fillInterior(tris)
+	g.buildSums()
+	return g, nil
+}
+
+func (g *voxelGrid) index(x, y, z int) int {
+	return (z*g.dims[1]+y)*g.dims[0] + x
+}
+
+// cellOf returns the cell containing the point.
+func (g *voxelGrid) cellOf(p mgl.Vec3) ([3]int, bool) {
+	var c [3]int
+	for i := 0; i < 3; i++ {
+		c[i] = int(math.Floor(float64((p[i] - g.origin[i]) / g.cellSize)))
+		if c[i] < 0 || c[i] >= g.dims[i] {
+			return c, false
+		}
+	}
+	return c, true
+}
+
+// isSolid returns true if the point is in a solid cell.
+func (g *voxelGrid) isSolid(p mgl.Vec3) bool {
+	c, okay := g.cellOf(p)
+	return okay && g.solid[g.index(c[0], c[1], c[2])]
+}
+
+// cellMin returns the minimum corner of the cell.
+func (g *voxelGrid) cellMin(x, y, z int) mgl.Vec3 {
+	return g.origin.Add(mgl.Vec3{float32(x), float32(y), float32(z)}.Mul(g.cellSize))
+}
+
+// addSurface samples the triangle finely enough to mark every cell it
+// passes through as solid, keeping the bounds of the samples in each cell.
+func (g *voxelGrid) addSurface(t trimesh.Triangle) {
+	ab := t[1].Sub(t[0])
+	ac := t[2].Sub(t[0])
+	longest := math.Max(float64(ab.Len()), math.Max(float64(ac.Len()), float64(t[2].Sub(t[1]).Len())))
+	n := int(math.Ceil(longest / float64(g.cellSize*0.5)))
+	if n < 1 {
+		n = 1
+	}
+
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n-i; j++ {
+			p := t[0].Add(ab.Mul(float32(i) / float32(n))).Add(ac.Mul(float32(j) / float32(n)))
+			c, okay := g.cellOf(p)
+			if !okay {
+				continue
+			}
+			idx := g.index(c[0], c[1], c[2])
+			if !g.surface[idx] {
+				g.surface[idx] = true
+				g.solid[idx] = true
+				g.surfaceMin[idx] = p
+				g.surfaceMax[idx] = p
+				continue
+			}
+			for k := 0; k < 3; k++ {
+				g.surfaceMin[idx][k] = float32(math.Min(float64(g.surfaceMin[idx][k]), float64(p[k])))
+				g.surfaceMax[idx][k] = float32(math.Max(float64(g.surfaceMax[idx][k]), float64(p[k])))
+			}
+		}
+	}
+}
+
+// fillInterior marks the cells inside the mesh as solid by casting a ray
+// along X through each row of cells and counting the surface crossings.
+// Rows with an odd number of crossings go through a hole in the mesh and
+// only keep their surface cells.
+func (g *voxelGrid) fillInterior(tris []trimesh.Triangle) {
+	// nudge the rays off the cell centers so they don't run along the
+	// shared edges of the triangles of axis-aligned meshes.
+	const nudge = 0.0137
+
+	var crossings []float64
+	for z := 0; z < g.dims[2]; z++ {
+		for y := 0; y < g.dims[1]; y++ {
+			center := g.cellMin(0, y, z).Add(mgl.Vec3{0.0, 0.5 + nudge, 0.5 - nudge}.Mul(g.cellSize))
+			crossings = crossings[:0]
+			for _, t := range tris {
+				if x, okay := crossingX(t, center[1], center[2]); okay {
+					crossings = append(crossings, x)
+				}
+			}
+			if len(crossings) == 0 || len(crossings)%2 != 0 {
+				continue
+			}
+			sort.Float64s(crossings)
+
+			for x := 0; x < g.dims[0]; x++ {
+				cx := float64(g.origin[0] + (float32(x)+0.5)*g.cellSize)
+				inside := sort.SearchFloat64s(crossings, cx)%2 == 1
+				if inside {
+					g.solid[g.index(x, y, z)] = true
+				}
+			}
+		}
+	}
+}
+
+// crossingX returns where a ray along X through (y, z) crosses the triangle.
+func crossingX(t trimesh.Triangle, y, z float32) (float64, bool) {
+	// barycentric coordinates of the point in the triangle projected on YZ
+	y0, z0 := float64(t[0][1]), float64(t[0][2])
+	y1, z1 := float64(t[1][1]), float64(t[1][2])
+	y2, z2 := float64(t[2][1]), float64(t[2][2])
+	det := (z1-z2)*(y0-y2) + (y2-y1)*(z0-z2)
+	if math.Abs(det) < 1e-12 {
+		return 0.0, false
+	}
+	py, pz := float64(y), float64(z)
+	a := ((z1-z2)*(py-y2) + (y2-y1)*(pz-z2)) / det
+	b := ((z2-z0)*(py-y2) + (y0-y2)*(pz-z2)) / det
+	c := 1.0 - a - b
+	if a < 0.0 || b < 0.0 || c < 0.0 {
+		return 0.0, false
+	}
+	return a*float64(t[0][0]) + b*float64(t[1][0]) + c*float64(t[2][0]), true
+}
+
+// buildSums builds the summed volume table of the solid cells.
+func (g *voxelGrid) buildSums() {
+	sx, sy := g.dims[0]+1, g.dims[1]+1
+	g.sums = make([]int, sx*sy*(g.dims[2]+1))
+	at := func(x, y, z int) int { return (z*sy+y)*sx + x }
+	for z := 1; z <= g.dims[2]; z++ {
+		for y := 1; y <= g.dims[1]; y++ {
+			for x := 1; x <= g.dims[0]; x++ {
+				v := 0
+				if g.solid[g.index(x-1, y-1, z-1)] {
+					v = 1
+				}
+				g.sums[at(x, y, z)] = v +
+					g.sums[at(x-1, y, z)] + g.sums[at(x, y-1, z)] + g.sums[at(x, y, z-1)] -
+					g.sums[at(x-1, y-1, z)] - g.sums[at(x-1, y, z-1)] - g.sums[at(x, y-1, z-1)] +
+					g.sums[at(x-1, y-1, z-1)]
+			}
+		}
+	}
+}
+
+// count returns the number of solid cells in the box.
+func (g *voxelGrid) count(b cellBox) int {
+	sx, sy := g.dims[0]+1, g.dims[1]+1
+	at := func(x, y, z int) int { return g.sums[(z*sy+y)*sx+x] }
+	x0, y0, z0 := b.lo[0], b.lo[1], b.lo[2]
+	x1, y1, z1 := b.hi[0]+1, b.hi[1]+1, b.hi[2]+1
+	return at(x1, y1, z1) -
+		at(x0, y1, z1) - at(x1, y0, z1) - at(x1, y1, z0) +
+		at(x0, y0, z1) + at(x0, y1, z0) + at(x1, y0, z0) -
+		at(x0, y0, z0)
+}
+
+// tighten shrinks the box to the solid cells in it. It returns false if
+// there are none.
+func (g *voxelGrid) tighten(b cellBox) (cellBox, bool) {
+	if g.count(b) == 0 {
+		return b, false
+	}
+	for axis := 0; axis < 3; axis++ {
+		for {
+			slab := b
+			slab.hi[axis] = slab.lo[axis]
+			if g.count(slab) > 0 {
+				break
+			}
+			b.lo[axis]++
+		}
+		for {
+			slab := b
+			slab.lo[axis] = slab.hi[axis]
+			if g.count(slab) > 0 {
+				break
+			}
+			b.hi[axis]--
+		}
+	}
+	return b, true
+}
+
+// split finds the plane that splits the box into the two tight boxes with
+// the least total volume. It returns false if no split reduces the volume.
+func (g *voxelGrid) split(b cellBox) (cellBox, cellBox, bool) {
+	bestVolume := b.volume()
+	var bestA, bestB cellBox
+	found := false
+	for axis := 0; axis < 3; axis++ {
+		for k := b.lo[axis]; k < b.hi[axis]; k++ {
+			a, c := b, b
+			a.hi[axis] = k
+			c.lo[axis] = k + 1
+			a, okayA := g.tighten(a)
+			c, okayC := g.tighten(c)
+			if !okayA || !okayC {
+				continue
+			}
+			if v := a.volume() + c.volume(); v < bestVolume {
+				bestVolume = v
+				bestA, bestB = a, c
+				found = true
+			}
+		}
+	}
+	return bestA, bestB, found
+}
+
+// worldBounds returns the bounds of the mesh inside the box: the surface in
+// the cells it passes through and the whole of the cells inside the mesh.
+func (g *voxelGrid) worldBounds(b cellBox) (mgl.Vec3, mgl.Vec3) {
+	var min, max mgl.Vec3
+	first := true
+	add := func(lo, hi mgl.Vec3) {
+		if first {
+			min, max = lo, hi
+			first = false
+			return
+		}
+		for i := 0; i < 3; i++ {
+			min[i] = float32(math.Min(float64(min[i]), float64(lo[i])))
+			max[i] = float32(math.Max(float64(max[i]), float64(hi[i])))
+		}
+	}
+
+	cell := mgl.Vec3{g.cellSize, g.cellSize, g.cellSize}
+	for z := b.lo[2]; z <= b.hi[2]; z++ {
+		for y := b.lo[1]; y <= b.hi[1]; y++ {
+			for x := b.lo[0]; x <= b.hi[0]; x++ {
+				idx := g.index(x, y, z)
+				if g.surface[idx] {
+					add(g.surfaceMin[idx], g.surfaceMax[idx])
+				} else if g.solid[idx] {
+					lo := g.cellMin(x, y, z)
+					add(lo, lo.Add(cell))
+				}
+			}
+		}
+	}
+	return min, max
+}
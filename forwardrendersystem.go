@@ -15,6 +15,8 @@ import (
 	opengl "github.com/tbogdala/fizzle/graphicsprovider/opengl"
 	forward "github.com/tbogdala/fizzle/renderer/forward"
 	"github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 const (
@@ -33,10 +35,10 @@ type ForwardRenderSystem struct {
 	visibleEntities []scene.Entity
 
 	// cachedPlayerEntity is the player entity that was added to the scene.
-	cachedPlayerEntity *VisibleEntity
+	cachedPlayerEntity *game.VisibleEntity
 
 	// cachedPlayerShipEntity is the player's ship entity that was added to the scene.
-	cachedPlayerShipEntity *ShipEntity
+	cachedPlayerShipEntity *game.ShipEntity
 }
 
 // NewForwardRenderSystem allocates a new ForwardRenderSystem object.
@@ -145,14 +147,14 @@ func (rs *ForwardRenderSystem) GetName() string {
 // OnAddEntity should get called by the scene Manager each time a new entity
 // has been added to the scene.
 func (rs *ForwardRenderSystem) OnAddEntity(newEntity scene.Entity) {
-	_, okay := newEntity.(RenderableEntity)
+	_, okay := newEntity.(game.RenderableEntity)
 	if okay {
 		rs.visibleEntities = append(rs.visibleEntities, newEntity)
 
-		if newEntity.GetName() == playerEntityName {
-			rs.cachedPlayerEntity = newEntity.(*VisibleEntity)
-		} else if newEntity.GetName() == playerShipEntityName {
-			rs.cachedPlayerShipEntity = newEntity.(*ShipEntity)
+		if newEntity.GetName() == game.PlayerEntityName {
+			rs.cachedPlayerEntity = newEntity.(*game.VisibleEntity)
+		} else if newEntity.GetName() == game.PlayerShipEntityName {
+			rs.cachedPlayerShipEntity = newEntity.(*game.ShipEntity)
 		}
 	}
 }
@@ -168,9 +170,9 @@ func (rs *ForwardRenderSystem) OnRemoveEntity(oldEntity scene.Entity) {
 	}
 	rs.visibleEntities = surviving
 
-	if oldEntity.GetName() == playerEntityName {
+	if oldEntity.GetName() == game.PlayerEntityName {
 		rs.cachedPlayerEntity = nil
-	} else if oldEntity.GetName() == playerShipEntityName {
+	} else if oldEntity.GetName() == game.PlayerShipEntityName {
 		rs.cachedPlayerShipEntity = nil
 	}
 }
@@ -191,12 +193,7 @@ func (rs *ForwardRenderSystem) Update(frameDelta float32) {
 	view := rs.Camera.GetViewMatrix()
 
 	// draw stuff the visible entities
-	for _, e := range rs.visibleEntities {
-		visibleEntity, okay := e.(RenderableEntity)
-		if okay {
-			if r := visibleEntity.GetRenderable(); r != nil {
-				rs.Renderer.DrawRenderable(r, nil, projection, view, rs.Camera)
-			}
-		}
-	}
+	drawVisibleEntities(rs.visibleEntities, func(r *fizzle.Renderable) {
+		rs.Renderer.DrawRenderable(r, nil, projection, view, rs.Camera)
+	})
 }
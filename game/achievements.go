@@ -0,0 +1,249 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"time"
+
+	"github.com/tbogdala/infinigrid/atomicfile"
+)
+
+const (
+	// AchievementsFileName is the file in the user data directory that
+	// stores the progress towards the achievements.
+	AchievementsFileName = "achievements.json"
+
+	// stillSpeedThreshold is the lateral speed, in m/s, below which the ship
+	// counts as not moving.
+	stillSpeedThreshold = 0.5
+)
+
+// The types of achievement. Each one tracks a different statistic that has
+// to reach the Goal of the achievement to unlock it.
+const (
+	// achievementTotalDistance is the distance travelled over all runs in meters.
+	achievementTotalDistance = "total_distance"
+
+	// achievementRunDistance is the distance travelled in a single run in meters.
+	achievementRunDistance = "run_distance"
+
+	// achievementNearMissStreak is the number of near misses in a single run.
+	achievementNearMissStreak = "near_miss_streak"
+
+	// achievementStillTime is the number of seconds survived without moving the ship.
+	achievementStillTime = "still_time"
+
+	// achievementRuns is the number of runs finished.
+	achievementRuns = "runs"
+
+	// achievementDailyRuns is the number of daily challenge runs finished.
+	achievementDailyRuns = "daily_runs"
+)
+
+// AchievementDef is the definition of an achievement.
+type AchievementDef struct {
+	ID          string
+	Name        string
+	Description string
+	Type        string
+	Goal        float64
+}
+
+// AchievementProgress is the progress towards an achievement.
+type AchievementProgress struct {
+	Progress float64
+
+	// Unlocked is when the achievement was unlocked or nil if it's still locked.
+	Unlocked *time.Time `json:",omitempty"`
+}
+
+// AchievementTracker updates the progress towards the achievements from
+// the events of the game.
+type AchievementTracker struct {
+	Defs     []*AchievementDef
+	Progress map[string]*AchievementProgress
+
+	// OnUnlock is called when an achievement gets unlocked.
+	OnUnlock func(def *AchievementDef)
+
+	filename string
+
+	// the statistics for the current run
+	runDistance    float64
+	nearMissStreak float64
+	stillTime      float64
+}
+
+// LoadGameAchievementDefs loads the achievement definitions the manifest of
+// the asset roots names from the highest priority root that has them.
+func LoadGameAchievementDefs() ([]*AchievementDef, error) {
+	manifest, err := LoadAssetManifest(AssetRoots)
+	if err != nil {
+		return nil, err
+	}
+	filename, err := manifest.ResolveAchievements()
+	if err != nil {
+		return nil, err
+	}
+	return LoadAchievementDefs(filename)
+}
+
+// LoadAchievementDefs loads the achievement definitions from the JSON file.
+func LoadAchievementDefs(filename string) ([]*AchievementDef, error) {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*AchievementDef
+	err = json.Unmarshal(jsonBytes, &defs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the achievements: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, def := range defs {
+		if def.ID == "" || ids[def.ID] {
+			return nil, fmt.Errorf("the achievement %q needs a unique ID", def.Name)
+		}
+		ids[def.ID] = true
+
+		switch def.Type {
+		case achievementTotalDistance, achievementRunDistance, achievementNearMissStreak,
+			achievementStillTime, achievementRuns, achievementDailyRuns:
+		default:
+			return nil, fmt.Errorf("the achievement %s has an unknown type %q", def.ID, def.Type)
+		}
+		if def.Goal <= 0.0 {
+			return nil, fmt.Errorf("the achievement %s needs a goal greater than zero", def.ID)
+		}
+	}
+	return defs, nil
+}
+
+// NewAchievementTracker creates a new tracker for the achievements with the
+// progress stored in the file specified. If the file does not exist yet, all
+// of the achievements start locked.
+func NewAchievementTracker(defs []*AchievementDef, filename string) (*AchievementTracker, error) {
+	t := new(AchievementTracker)
+	t.Defs = defs
+	t.Progress = make(map[string]*AchievementProgress)
+	t.filename = filename
+
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return t, nil
+	} else if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(jsonBytes, &t.Progress)
+	if err != nil {
+		t.Progress = make(map[string]*AchievementProgress)
+		return t, fmt.Errorf("failed to parse the achievement progress: %v", err)
+	}
+	return t, nil
+}
+
+// Save writes the progress back out to the file it was loaded from.
+func (t *AchievementTracker) Save() error {
+	jsonBytes, err := json.MarshalIndent(t.Progress, "", "    ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(t.filename, jsonBytes)
+}
+
+// getProgress returns the progress for the achievement, creating it if needed.
+func (t *AchievementTracker) getProgress(def *AchievementDef) *AchievementProgress {
+	p, okay := t.Progress[def.ID]
+	if !okay {
+		p = new(AchievementProgress)
+		t.Progress[def.ID] = p
+	}
+	return p
+}
+
+// update changes the progress of all of the locked achievements of the type
+// using the function and unlocks the ones that reach their goal.
+func (t *AchievementTracker) update(achievementType string, progress func(current float64) float64) {
+	for _, def := range t.Defs {
+		if def.Type != achievementType {
+			continue
+		}
+		p := t.getProgress(def)
+		if p.Unlocked != nil {
+			continue
+		}
+
+		p.Progress = progress(p.Progress)
+		if p.Progress >= def.Goal {
+			p.Progress = def.Goal
+			now := time.Now().UTC()
+			p.Unlocked = &now
+			if t.OnUnlock != nil {
+				t.OnUnlock(def)
+			}
+		}
+	}
+}
+
+// add increases the progress of the achievements of the type by amount.
+func (t *AchievementTracker) add(achievementType string, amount float64) {
+	t.update(achievementType, func(current float64) float64 {
+		return current + amount
+	})
+}
+
+// best sets the progress of the achievements of the type to value if it's higher.
+func (t *AchievementTracker) best(achievementType string, value float64) {
+	t.update(achievementType, func(current float64) float64 {
+		return math.Max(current, value)
+	})
+}
+
+// StartRun resets the statistics for a new run.
+func (t *AchievementTracker) StartRun() {
+	t.runDistance = 0.0
+	t.nearMissStreak = 0.0
+	t.stillTime = 0.0
+}
+
+// Update tracks the ship for a frame of play. distance is how far the ship
+// travelled during the frame and lateralSpeed is its speed across the tunnel.
+func (t *AchievementTracker) Update(frameDelta float32, distance float64, lateralSpeed float32) {
+	t.runDistance += distance
+	t.add(achievementTotalDistance, distance)
+	t.best(achievementRunDistance, t.runDistance)
+
+	if lateralSpeed < stillSpeedThreshold {
+		t.stillTime += float64(frameDelta)
+		t.best(achievementStillTime, t.stillTime)
+	} else {
+		t.stillTime = 0.0
+	}
+}
+
+// NearMiss tracks a bomb passing close to the ship.
+func (t *AchievementTracker) NearMiss() {
+	t.nearMissStreak++
+	t.best(achievementNearMissStreak, t.nearMissStreak)
+}
+
+// RunEnded tracks the end of a run in the game mode and saves the progress.
+func (t *AchievementTracker) RunEnded(mode string) {
+	t.add(achievementRuns, 1.0)
+	if mode == GameModeDaily {
+		t.add(achievementDailyRuns, 1.0)
+	}
+
+	err := t.Save()
+	if err != nil {
+		fmt.Printf("Could not save the achievements: %v\n", err)
+	}
+}
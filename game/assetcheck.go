@@ -0,0 +1,234 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	component "github.com/tbogdala/fizzle/component"
+)
+
+// lfsPointerHeader is how a git-lfs pointer file starts. These get left in
+// place of the real files when git-lfs isn't set up before cloning.
+var lfsPointerHeader = []byte("version https://git-lfs")
+
+// AssetProblem is a problem found while validating the assets.
+type AssetProblem struct {
+	// Fatal problems stop the game from running; the others are warnings.
+	Fatal bool
+
+	// File is the component file the problem was found in.
+	File string
+
+	Message string
+}
+
+// AssetReport is the result of validating the assets.
+type AssetReport struct {
+	Roots      []string
+	Components int
+	Problems   []AssetProblem
+
+	// LFSPointers counts the files that are git-lfs pointers.
+	LFSPointers int
+}
+
+func (r *AssetReport) addError(file string, format string, a ...interface{}) {
+	r.Problems = append(r.Problems, AssetProblem{Fatal: true, File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+func (r *AssetReport) addWarning(file string, format string, a ...interface{}) {
+	r.Problems = append(r.Problems, AssetProblem{Fatal: false, File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+// HasErrors returns true if any of the problems would stop the game from running.
+func (r *AssetReport) HasErrors() bool {
+	for _, p := range r.Problems {
+		if p.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// Write prints the problems along with what to do about them.
+func (r *AssetReport) Write(w io.Writer) {
+	errors, warnings := 0, 0
+	for _, p := range r.Problems {
+		label := "WARNING"
+		if p.Fatal {
+			label = "ERROR  "
+			errors++
+		} else {
+			warnings++
+		}
+		if p.File == "" {
+			fmt.Fprintf(w, "%s %s\n", label, p.Message)
+		} else {
+			fmt.Fprintf(w, "%s %s: %s\n", label, p.File, p.Message)
+		}
+	}
+	fmt.Fprintf(w, "Checked %d component(s) in %s: %d error(s), %d warning(s).\n",
+		r.Components, strings.Join(r.Roots, ", "), errors, warnings)
+
+	if r.LFSPointers > 0 {
+		fmt.Fprintf(w, "\n%d file(s) are git-lfs pointers instead of the real assets. Install git-lfs\n", r.LFSPointers)
+		fmt.Fprintf(w, "from https://git-lfs.github.com/ and then run this in the repository to fetch them:\n")
+		fmt.Fprintf(w, "    git lfs install\n    git lfs pull\n")
+	}
+}
+
+// isLFSPointer returns true if the file is a git-lfs pointer.
+func isLFSPointer(filename string) (bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(lfsPointerHeader))
+	n, err := io.ReadFull(f, header)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return bytes.Equal(header[:n], lfsPointerHeader), nil
+}
+
+// checkAssetFile checks that a file referenced by the component exists and
+// isn't a git-lfs pointer. Problems with required files are fatal.
+func (r *AssetReport) checkAssetFile(componentFile string, componentDir string, what string, path string, required bool) {
+	if path == "" {
+		return
+	}
+	add := r.addWarning
+	if required {
+		add = r.addError
+	}
+
+	filename := filepath.Join(componentDir, path)
+	isPointer, err := isLFSPointer(filename)
+	if os.IsNotExist(err) {
+		add(componentFile, "%s %s does not exist", what, path)
+	} else if err != nil {
+		add(componentFile, "%s %s could not be read: %v", what, path, err)
+	} else if isPointer {
+		r.LFSPointers++
+		add(componentFile, "%s %s is a git-lfs pointer", what, path)
+	}
+}
+
+// checkColliders checks that the colliders of the component have a size and
+// that their tags only name known collision layers.
+func (r *AssetReport) checkColliders(componentFile string, c *component.Component) {
+	for i, col := range c.Collisions {
+		switch col.Type {
+		case component.ColliderTypeSphere:
+			if col.Radius <= 0.0 {
+				r.addError(componentFile, "sphere collider %d has a radius of %v; it needs to be greater than zero", i, col.Radius)
+			}
+		case component.ColliderTypeAABB:
+			for axis := 0; axis < 3; axis++ {
+				if col.Min[axis] >= col.Max[axis] {
+					r.addError(componentFile, "AABB collider %d has Min %v that isn't less than Max %v on every axis", i, col.Min, col.Max)
+					break
+				}
+			}
+		default:
+			r.addError(componentFile, "collider %d has an unknown type %d", i, col.Type)
+		}
+		if _, err := parseColliderLayers(col.Tags, layerHazard); err != nil {
+			r.addError(componentFile, "collider %d: %v", i, err)
+		}
+	}
+}
+
+// checkComponent validates a component file and the files it references.
+func (r *AssetReport) checkComponent(filename string) {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		r.addError(filename, "could not be read: %v", err)
+		return
+	}
+	c := new(component.Component)
+	err = json.Unmarshal(jsonBytes, c)
+	if err != nil {
+		r.addError(filename, "is not a valid component: %v", err)
+		return
+	}
+
+	componentDir := filepath.Dir(filename)
+	for _, compMesh := range c.Meshes {
+		mesh := fmt.Sprintf("mesh %s", compMesh.Name)
+		r.checkAssetFile(filename, componentDir, mesh+" BinFile", compMesh.BinFile, true)
+		r.checkAssetFile(filename, componentDir, mesh+" DiffuseTexture", compMesh.Material.DiffuseTexture, true)
+		r.checkAssetFile(filename, componentDir, mesh+" NormalsTexture", compMesh.Material.NormalsTexture, true)
+		r.checkAssetFile(filename, componentDir, mesh+" SpecularTexture", compMesh.Material.SpecularTexture, true)
+
+		// the source models are only needed to rebuild the binary ones
+		r.checkAssetFile(filename, componentDir, mesh+" SrcFile", compMesh.SrcFile, false)
+	}
+	r.checkColliders(filename, c)
+
+	if _, err := usesNarrowPhase(c); err != nil {
+		r.addError(filename, "%v", err)
+	}
+	r.checkAssetFile(filename, componentDir, "collision mesh", c.Properties[collisionMeshProperty], true)
+}
+
+// ValidateAssets checks every component in the manifest of the asset roots.
+func ValidateAssets(roots []string) *AssetReport {
+	r := new(AssetReport)
+	r.Roots = roots
+
+	manifest, err := LoadAssetManifest(roots)
+	if err != nil {
+		r.addError("", "%v", err)
+		return r
+	}
+
+	componentFiles, err := manifest.ResolveComponents(requiredComponents)
+	if missingErr, okay := err.(*MissingAssetsError); okay {
+		for _, m := range missingErr.Missing {
+			if m.Path == "" {
+				r.addError("", "the component %s is not in any manifest", m.Name)
+			} else {
+				r.addError("", "the component %s file %s does not exist", m.Name, m.Path)
+			}
+		}
+
+		// still check the components that could be found
+		componentFiles = make(map[string]string)
+		for _, name := range manifest.ComponentNames() {
+			if filename, okay := manifest.ResolvePath(manifest.Components[name]); okay {
+				componentFiles[name] = filename
+			}
+		}
+	} else if err != nil {
+		r.addError("", "%v", err)
+		return r
+	}
+
+	for _, name := range sortedKeys(componentFiles) {
+		r.Components++
+		r.checkComponent(componentFiles[name])
+	}
+
+	// the game runs without achievements so they're only a warning
+	achievementsFile, err := manifest.ResolveAchievements()
+	if err != nil {
+		r.addWarning("", "achievements are disabled. %v", err)
+	} else if _, err = LoadAchievementDefs(achievementsFile); err != nil {
+		r.addWarning(achievementsFile, "achievements are disabled. %v", err)
+	}
+	return r
+}
@@ -0,0 +1,206 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultAssetRoot is the directory the game's own assets are in.
+	DefaultAssetRoot = "assets"
+
+	// assetManifestFileName is the name of the manifest file in an asset root.
+	assetManifestFileName = "manifest.json"
+)
+
+// The names of the components the game needs to run.
+const (
+	shipComponentName = "entity/ship"
+	bombComponentName = "entity/bomb"
+	gridComponentName = "grid/proto"
+)
+
+// achievementsAssetName is the name the achievement definitions are
+// reported under when they're missing.
+const achievementsAssetName = "achievements"
+
+var (
+	// AssetRoots are the directories assets are loaded from in priority
+	// order; the first root that has an asset overrides the ones after it.
+	AssetRoots = []string{DefaultAssetRoot}
+
+	// requiredComponents are the components that must be in the manifest.
+	requiredComponents = []string{shipComponentName, bombComponentName, gridComponentName}
+)
+
+// assetManifestFile is the format of the manifest file in an asset root.
+// The paths are relative to the asset root.
+type assetManifestFile struct {
+	Components   map[string]string
+	Achievements string
+}
+
+// MissingAsset is an asset that could not be found in any of the asset roots.
+type MissingAsset struct {
+	// Name is the logical name of the asset, such as "entity/ship".
+	Name string
+
+	// Path is the file the manifest maps the name to or empty if the name
+	// isn't in any manifest.
+	Path string
+}
+
+// MissingAssetsError is returned when assets can't be found and lists
+// every one of them.
+type MissingAssetsError struct {
+	Roots   []string
+	Missing []MissingAsset
+}
+
+// Error lists the missing assets.
+func (e *MissingAssetsError) Error() string {
+	lines := []string{fmt.Sprintf("%d asset(s) missing from %s:", len(e.Missing), strings.Join(e.Roots, ", "))}
+	for _, m := range e.Missing {
+		if m.Path == "" {
+			lines = append(lines, fmt.Sprintf("  %s: not in any manifest", m.Name))
+		} else {
+			lines = append(lines, fmt.Sprintf("  %s: %s not found", m.Name, m.Path))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssetManifest maps the logical names of assets to the files they're
+// loaded from, merged over a set of asset roots.
+type AssetManifest struct {
+	// Roots are the asset directories in priority order.
+	Roots []string
+
+	// Components maps the component names to their paths relative to an
+	// asset root.
+	Components map[string]string
+
+	// Achievements is the path of the achievement definitions relative to
+	// an asset root or empty if no manifest has them.
+	Achievements string
+}
+
+// LoadAssetManifest loads and merges the manifests of the asset roots. An
+// entry in a root overrides entries of the same name in the roots after it.
+// Roots without a manifest can still override the files of other roots.
+func LoadAssetManifest(roots []string) (*AssetManifest, error) {
+	m := new(AssetManifest)
+	m.Roots = roots
+	m.Components = make(map[string]string)
+
+	foundManifest := false
+	for i := len(roots) - 1; i >= 0; i-- {
+		filename := filepath.Join(roots[i], assetManifestFileName)
+		jsonBytes, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var mf assetManifestFile
+		err = json.Unmarshal(jsonBytes, &mf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the asset manifest %s: %v", filename, err)
+		}
+		for name, path := range mf.Components {
+			m.Components[name] = filepath.FromSlash(path)
+		}
+		if mf.Achievements != "" {
+			m.Achievements = filepath.FromSlash(mf.Achievements)
+		}
+		foundManifest = true
+	}
+
+	if !foundManifest {
+		return nil, fmt.Errorf("no %s found in the asset roots %s", assetManifestFileName, strings.Join(roots, ", "))
+	}
+	return m, nil
+}
+
+// ResolvePath returns the file for the path relative to an asset root from
+// the highest priority root that has it.
+func (m *AssetManifest) ResolvePath(path string) (string, bool) {
+	for _, root := range m.Roots {
+		filename := filepath.Join(root, path)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, true
+		}
+	}
+	return "", false
+}
+
+// ComponentNames returns the sorted names of all of the components in the manifest.
+func (m *AssetManifest) ComponentNames() []string {
+	names := make([]string, 0, len(m.Components))
+	for name := range m.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveComponents returns the files for all of the components in the
+// manifest. If the required components aren't in the manifest or any of the
+// files can't be found a *MissingAssetsError lists every one of them.
+func (m *AssetManifest) ResolveComponents(required []string) (map[string]string, error) {
+	var missing []MissingAsset
+	for _, name := range required {
+		if _, okay := m.Components[name]; !okay {
+			missing = append(missing, MissingAsset{Name: name})
+		}
+	}
+
+	files := make(map[string]string)
+	for _, name := range m.ComponentNames() {
+		path := m.Components[name]
+		filename, okay := m.ResolvePath(path)
+		if !okay {
+			missing = append(missing, MissingAsset{Name: name, Path: path})
+			continue
+		}
+		files[name] = filename
+	}
+
+	if len(missing) > 0 {
+		return nil, &MissingAssetsError{Roots: m.Roots, Missing: missing}
+	}
+	return files, nil
+}
+
+// ResolveAchievements returns the file for the achievement definitions. If
+// they aren't in the manifest or the file can't be found a *MissingAssetsError
+// says which.
+func (m *AssetManifest) ResolveAchievements() (string, error) {
+	if m.Achievements == "" {
+		return "", &MissingAssetsError{Roots: m.Roots, Missing: []MissingAsset{{Name: achievementsAssetName}}}
+	}
+	filename, okay := m.ResolvePath(m.Achievements)
+	if !okay {
+		return "", &MissingAssetsError{Roots: m.Roots, Missing: []MissingAsset{{Name: achievementsAssetName, Path: m.Achievements}}}
+	}
+	return filename, nil
+}
+
+// resolveGameComponents loads the manifest of the asset roots and returns
+// the files for the components the game loads.
+func resolveGameComponents() (map[string]string, error) {
+	manifest, err := LoadAssetManifest(AssetRoots)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ResolveComponents(requiredComponents)
+}
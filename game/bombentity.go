@@ -1,7 +1,7 @@
 // Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
 // See the LICENSE file for more details.
 
-package main
+package game
 
 import (
 	"math"
@@ -24,12 +24,14 @@ type BombEntity struct {
 	movementCurveXOffset float64
 }
 
-// NewBombEntity returns a new bomb entity object.
-func NewBombEntity() *BombEntity {
+// NewBombEntity returns a new bomb entity object. The movement curve
+// of the bomb is randomized with the rng supplied.
+func NewBombEntity(rng *rand.Rand) *BombEntity {
 	b := new(BombEntity)
 	b.VisibleEntity = NewVisibleEntity()
-	b.movementCurveYOffset = rand.Float64() * 2.0
-	b.movementCurveXOffset = rand.Float64() * 2.0
+	b.CollisionLayer = layerHazard
+	b.movementCurveYOffset = rng.Float64() * 2.0
+	b.movementCurveXOffset = rng.Float64() * 2.0
 	return b
 }
 
@@ -41,14 +43,14 @@ func (b *BombEntity) SetMaxSpeed() {
 
 // ScrollPastPlayer should move the entity with relation to the inverse
 // of the player speed, adjusted for frame delta.
-func (b *BombEntity) ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32) {
+func (b *BombEntity) ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32, gameTime float64) {
 	// in addition to the normal backward speed we're going to add
 	// the speed of the bomb.
 	totalSpeed := backwardSpeed.Add(b.currentSpeed.Mul(frameDelta))
 
 	// now we do a little wave adjustment
-	totalSpeed[0] = totalSpeed[0] + float32(math.Cos(gameScene.currentGameTime+b.movementCurveXOffset))*frameDelta
-	totalSpeed[1] = totalSpeed[1] + float32(math.Sin(gameScene.currentGameTime+b.movementCurveYOffset))*frameDelta
+	totalSpeed[0] = totalSpeed[0] + float32(math.Cos(gameTime+b.movementCurveXOffset))*frameDelta
+	totalSpeed[1] = totalSpeed[1] + float32(math.Sin(gameTime+b.movementCurveYOffset))*frameDelta
 
 	// move everything else back the current speed of the ship
 	loc := b.GetLocation().Add(totalSpeed)
@@ -59,3 +61,8 @@ func (b *BombEntity) ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32
 func (b *BombEntity) GetColliders() []glider.Collider {
 	return b.VisibleEntity.CoarseColliders
 }
+
+// GetVelocity returns the velocity of the bomb relative to the grid.
+func (b *BombEntity) GetVelocity() mgl.Vec3 {
+	return b.currentSpeed
+}
@@ -0,0 +1,197 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	scene "github.com/tbogdala/fizzle/scene"
+	"github.com/tbogdala/glider"
+)
+
+// CollisionEvent describes a collider of one entity hitting a collider of
+// another.
+type CollisionEvent struct {
+	// A and B are the entities that collided. Subscribers get A as the
+	// entity with the lower id; handlers get A on the first layer they handle.
+	A, B scene.Entity
+
+	// ColliderA and ColliderB are the indexes of the colliders that hit in
+	// the colliders of each entity.
+	ColliderA, ColliderB int
+
+	// LayersA and LayersB are the collision layers of the colliders.
+	LayersA, LayersB ColliderLayers
+
+	// Point is roughly where the colliders touch and Normal is the unit
+	// direction from A towards B at that point.
+	Point  mgl.Vec3
+	Normal mgl.Vec3
+
+	// RelativeVelocity is the velocity of B as seen from A in m/s.
+	RelativeVelocity mgl.Vec3
+}
+
+// MovingEntity is an entity that moves relative to the grid on its own.
+// Entities that don't implement it are considered still.
+type MovingEntity interface {
+	// GetVelocity returns the velocity of the entity relative to the grid in m/s.
+	GetVelocity() mgl.Vec3
+}
+
+// IsTrigger returns true if either collider is a trigger.
+func (e *CollisionEvent) IsTrigger() bool {
+	return (e.LayersA.Layers|e.LayersB.Layers)&layerTrigger != 0
+}
+
+// Reversed returns the event as seen from B.
+func (e *CollisionEvent) Reversed() *CollisionEvent {
+	return &CollisionEvent{
+		A:                e.B,
+		B:                e.A,
+		ColliderA:        e.ColliderB,
+		ColliderB:        e.ColliderA,
+		LayersA:          e.LayersB,
+		LayersB:          e.LayersA,
+		Point:            e.Point,
+		Normal:           e.Normal.Mul(-1.0),
+		RelativeVelocity: e.RelativeVelocity.Mul(-1.0),
+	}
+}
+
+// entityVelocity returns the velocity of the entity relative to the grid.
+func entityVelocity(e scene.Entity) mgl.Vec3 {
+	if me, okay := e.(MovingEntity); okay {
+		return me.GetVelocity()
+	}
+	return mgl.Vec3{}
+}
+
+// findCollisions returns an event for each pair of colliders of the two
+// entities that are on layers that collide and that hit each other.
+func findCollisions(a, b *collisionBody) []*CollisionEvent {
+	var events []*CollisionEvent
+	for i, colA := range a.colliders {
+		for j, colB := range b.colliders {
+			la, lb := a.layers[i], b.layers[j]
+			if !la.interacts(lb) || glider.Collide(colA, colB) == glider.NoIntersect {
+				continue
+			}
+			event := &CollisionEvent{
+				A:         a.entity,
+				B:         b.entity,
+				ColliderA: i,
+				ColliderB: j,
+				LayersA:   la,
+				LayersB:   lb,
+			}
+			event.Point, event.Normal = contact(colA, colB, a.entity.GetLocation(), b.entity.GetLocation())
+			event.RelativeVelocity = entityVelocity(b.entity).Sub(entityVelocity(a.entity))
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// contact returns the approximate contact point of two overlapping colliders
+// and the normal from a towards b. Colliders of unknown types use the
+// locations of their entities.
+func contact(a, b glider.Collider, locA, locB mgl.Vec3) (mgl.Vec3, mgl.Vec3) {
+	switch ca := a.(type) {
+	case *glider.Sphere:
+		switch cb := b.(type) {
+		case *glider.Sphere:
+			return contactSpheres(ca, cb)
+		case *glider.AABBox:
+			return contactSphereBox(ca, cb)
+		}
+	case *glider.AABBox:
+		switch cb := b.(type) {
+		case *glider.Sphere:
+			point, normal := contactSphereBox(cb, ca)
+			return point, normal.Mul(-1.0)
+		case *glider.AABBox:
+			return contactBoxes(ca, cb)
+		}
+	}
+	return locA.Add(locB).Mul(0.5), directionOr(locB.Sub(locA), mgl.Vec3{0.0, 0.0, 1.0})
+}
+
+// directionOr returns v normalized, or fallback if v has no length.
+func directionOr(v, fallback mgl.Vec3) mgl.Vec3 {
+	if v.Len() == 0.0 {
+		return fallback
+	}
+	return v.Normalize()
+}
+
+// contactSpheres returns the middle of the overlap of the spheres along the
+// line between their centers.
+func contactSpheres(a, b *glider.Sphere) (mgl.Vec3, mgl.Vec3) {
+	centerA := a.Center.Add(a.Offset)
+	centerB := b.Center.Add(b.Offset)
+	between := centerB.Sub(centerA)
+	normal := directionOr(between, mgl.Vec3{0.0, 0.0, 1.0})
+	depth := (between.Len() + a.Radius - b.Radius) * 0.5
+	return centerA.Add(normal.Mul(depth)), normal
+}
+
+// contactSphereBox returns the point of the box closest to the center of
+// the sphere. If the center is inside the box the normal is along the axis
+// of the nearest face.
+func contactSphereBox(a *glider.Sphere, b *glider.AABBox) (mgl.Vec3, mgl.Vec3) {
+	center := a.Center.Add(a.Offset)
+	min := b.Min.Add(b.Offset)
+	max := b.Max.Add(b.Offset)
+
+	var closest mgl.Vec3
+	for i := 0; i < 3; i++ {
+		closest[i] = mgl.Clamp(center[i], min[i], max[i])
+	}
+	if toBox := closest.Sub(center); toBox.Len() > 0.0 {
+		return closest, toBox.Normalize()
+	}
+
+	// the center is inside the box so push out through the nearest face
+	var normal mgl.Vec3
+	nearest := float32(math.MaxFloat32)
+	for i := 0; i < 3; i++ {
+		if d := center[i] - min[i]; d < nearest {
+			nearest = d
+			normal = mgl.Vec3{}
+			normal[i] = 1.0
+		}
+		if d := max[i] - center[i]; d < nearest {
+			nearest = d
+			normal = mgl.Vec3{}
+			normal[i] = -1.0
+		}
+	}
+	return center, normal
+}
+
+// contactBoxes returns the center of the overlap of the boxes and the normal
+// along the axis they overlap the least on.
+func contactBoxes(a, b *glider.AABBox) (mgl.Vec3, mgl.Vec3) {
+	minA, maxA := a.Min.Add(a.Offset), a.Max.Add(a.Offset)
+	minB, maxB := b.Min.Add(b.Offset), b.Max.Add(b.Offset)
+
+	var point, normal mgl.Vec3
+	smallest := float32(math.MaxFloat32)
+	for i := 0; i < 3; i++ {
+		lo := float32(math.Max(float64(minA[i]), float64(minB[i])))
+		hi := float32(math.Min(float64(maxA[i]), float64(maxB[i])))
+		point[i] = (lo + hi) * 0.5
+		if overlap := hi - lo; overlap < smallest {
+			smallest = overlap
+			normal = mgl.Vec3{}
+			normal[i] = 1.0
+			if minB[i]+maxB[i] < minA[i]+maxA[i] {
+				normal[i] = -1.0
+			}
+		}
+	}
+	return point, normal
+}
@@ -0,0 +1,117 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/glider"
+)
+
+// movingTestEntity is an entity with a fixed velocity.
+type movingTestEntity struct {
+	*VisibleEntity
+	velocity mgl.Vec3
+}
+
+func (e *movingTestEntity) GetVelocity() mgl.Vec3 {
+	return e.velocity
+}
+
+// newTestBody returns a collision body for a moving entity at the location
+// with the colliders all on the layer. The colliders are offset by the location.
+func newTestBody(name string, location, velocity mgl.Vec3, layer CollisionLayer, colliders ...glider.Collider) *collisionBody {
+	ve := NewVisibleEntity()
+	ve.Name = name
+	ve.SetLocation(location)
+	body := &collisionBody{entity: &movingTestEntity{VisibleEntity: ve, velocity: velocity}}
+	for _, col := range colliders {
+		col.SetOffset(&location)
+		body.colliders = append(body.colliders, col)
+		body.layers = append(body.layers, defaultColliderLayers(layer))
+	}
+	return body
+}
+
+func TestFindCollisions(t *testing.T) {
+	// far away colliders come first so that the index of the one that hits is checked
+	missSphere := func() glider.Collider {
+		return &glider.Sphere{Center: mgl.Vec3{0.0, 50.0, 0.0}, Radius: 0.1}
+	}
+	box := func(min, max mgl.Vec3) glider.Collider {
+		return &glider.AABBox{Min: min, Max: max}
+	}
+
+	tests := []struct {
+		name    string
+		a, b    *collisionBody
+		point   mgl.Vec3
+		normal  mgl.Vec3
+		indexes [2]int
+	}{
+		{
+			name: "sphere and box",
+			a: newTestBody("Ship", mgl.Vec3{0.0, 0.0, 0.0}, mgl.Vec3{0.0, 0.0, 10.0}, layerShip,
+				missSphere(), &glider.Sphere{Radius: 1.0}),
+			b: newTestBody("Wall", mgl.Vec3{1.5, 0.0, 0.0}, mgl.Vec3{}, layerWall,
+				box(mgl.Vec3{-1.0, -1.0, -1.0}, mgl.Vec3{1.0, 1.0, 1.0})),
+			point:   mgl.Vec3{0.5, 0.0, 0.0},
+			normal:  mgl.Vec3{1.0, 0.0, 0.0},
+			indexes: [2]int{1, 0},
+		},
+		{
+			name: "box and sphere",
+			a: newTestBody("Wall", mgl.Vec3{1.5, 0.0, 0.0}, mgl.Vec3{}, layerWall,
+				box(mgl.Vec3{-1.0, -1.0, -1.0}, mgl.Vec3{1.0, 1.0, 1.0})),
+			b: newTestBody("Ship", mgl.Vec3{0.0, 0.0, 0.0}, mgl.Vec3{0.0, 0.0, 10.0}, layerShip,
+				missSphere(), &glider.Sphere{Radius: 1.0}),
+			point:   mgl.Vec3{0.5, 0.0, 0.0},
+			normal:  mgl.Vec3{-1.0, 0.0, 0.0},
+			indexes: [2]int{0, 1},
+		},
+		{
+			name: "box and box",
+			a: newTestBody("Ship", mgl.Vec3{0.0, 0.0, 0.0}, mgl.Vec3{0.0, 0.0, 10.0}, layerShip,
+				box(mgl.Vec3{-1.0, -1.0, -1.0}, mgl.Vec3{1.0, 1.0, 1.0})),
+			b: newTestBody("Bomb", mgl.Vec3{0.2, -1.5, 0.0}, mgl.Vec3{0.0, 0.0, -5.0}, layerHazard,
+				missSphere(), box(mgl.Vec3{-1.0, -1.0, -1.0}, mgl.Vec3{1.0, 1.0, 1.0})),
+			point:   mgl.Vec3{0.1, -0.75, 0.0},
+			normal:  mgl.Vec3{0.0, -1.0, 0.0},
+			indexes: [2]int{0, 1},
+		},
+	}
+
+	for _, test := range tests {
+		events := findCollisions(test.a, test.b)
+		if len(events) != 1 {
+			t.Errorf("%s: expected 1 collision, got %d", test.name, len(events))
+			continue
+		}
+		event := events[0]
+		if event.A != test.a.entity || event.B != test.b.entity {
+			t.Errorf("%s: the event is between %s and %s", test.name, event.A.GetName(), event.B.GetName())
+		}
+		if event.ColliderA != test.indexes[0] || event.ColliderB != test.indexes[1] {
+			t.Errorf("%s: expected colliders %v to hit, got %d and %d", test.name, test.indexes, event.ColliderA, event.ColliderB)
+		}
+		if !vec3ApproxEqual(event.Point, test.point) {
+			t.Errorf("%s: expected the contact at %v, got %v", test.name, test.point, event.Point)
+		}
+		if !vec3ApproxEqual(event.Normal, test.normal) {
+			t.Errorf("%s: expected the normal %v, got %v", test.name, test.normal, event.Normal)
+		}
+
+		velocity := entityVelocity(test.b.entity).Sub(entityVelocity(test.a.entity))
+		if !vec3ApproxEqual(event.RelativeVelocity, velocity) {
+			t.Errorf("%s: expected the relative velocity %v, got %v", test.name, velocity, event.RelativeVelocity)
+		}
+
+		reversed := event.Reversed()
+		if reversed.A != event.B || reversed.ColliderA != event.ColliderB ||
+			!vec3ApproxEqual(reversed.Normal, event.Normal.Mul(-1.0)) {
+			t.Errorf("%s: the reversed event doesn't swap the entities and the normal", test.name)
+		}
+	}
+}
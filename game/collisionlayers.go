@@ -0,0 +1,271 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	scene "github.com/tbogdala/fizzle/scene"
+	"github.com/tbogdala/glider"
+)
+
+// CollisionLayer is a set of collision categories as bit flags.
+type CollisionLayer uint32
+
+// The collision layers. A collider is put on layers by listing their names
+// in the Tags of the collider in the component file.
+const (
+	layerShip CollisionLayer = 1 << iota
+	layerWall
+	layerHazard
+	layerPickup
+	layerTrigger
+	layerProjectile
+)
+
+// collidesTagPrefix starts a tag naming a layer the collider collides with,
+// such as "collides:ship". A collider with any of these tags only collides
+// with the layers they name instead of the default mask of its layers.
+const collidesTagPrefix = "collides:"
+
+// collisionLayers lists every layer in the order collisions between them
+// are handled.
+var collisionLayers = []CollisionLayer{
+	layerShip,
+	layerWall,
+	layerHazard,
+	layerPickup,
+	layerTrigger,
+	layerProjectile,
+}
+
+// collisionLayerNames maps the names used in tags to the layers.
+var collisionLayerNames = map[string]CollisionLayer{
+	"ship":       layerShip,
+	"wall":       layerWall,
+	"hazard":     layerHazard,
+	"pickup":     layerPickup,
+	"trigger":    layerTrigger,
+	"projectile": layerProjectile,
+}
+
+// defaultCollisionMasks are the layers each layer collides with unless the
+// tags of a collider say otherwise.
+var defaultCollisionMasks = map[CollisionLayer]CollisionLayer{
+	layerShip:       layerWall | layerHazard | layerPickup | layerTrigger,
+	layerWall:       layerShip | layerProjectile,
+	layerHazard:     layerShip | layerProjectile,
+	layerPickup:     layerShip,
+	layerTrigger:    layerShip,
+	layerProjectile: layerWall | layerHazard,
+}
+
+// ColliderLayers are the layers a collider is on and the layers it collides with.
+type ColliderLayers struct {
+	Layers CollisionLayer
+	Mask   CollisionLayer
+}
+
+// defaultColliderLayers returns the layers for a collider only on the layers
+// specified, colliding with their default masks.
+func defaultColliderLayers(layers CollisionLayer) ColliderLayers {
+	l := ColliderLayers{Layers: layers}
+	for layer, mask := range defaultCollisionMasks {
+		if layers&layer != 0 {
+			l.Mask |= mask
+		}
+	}
+	return l
+}
+
+// parseColliderLayers returns the layers named in the tags of a collider.
+// Tags that aren't layer names are left for other uses. An unknown layer in
+// a "collides:" tag is an error but the layers from the rest of the tags are
+// still returned. If the tags name no layers, fallback is used.
+func parseColliderLayers(tags []string, fallback CollisionLayer) (ColliderLayers, error) {
+	var layers, mask CollisionLayer
+	var err error
+	hasMask := false
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, collidesTagPrefix) {
+			name := strings.TrimPrefix(tag, collidesTagPrefix)
+			layer, okay := collisionLayerNames[name]
+			if !okay {
+				err = fmt.Errorf("the tag %q names an unknown collision layer %q", tag, name)
+				continue
+			}
+			mask |= layer
+			hasMask = true
+			continue
+		}
+		if layer, okay := collisionLayerNames[tag]; okay {
+			layers |= layer
+		}
+	}
+	if layers == 0 {
+		layers = fallback
+	}
+
+	l := defaultColliderLayers(layers)
+	if hasMask {
+		l.Mask = mask
+	}
+	return l, err
+}
+
+// interacts returns true if each of the colliders is on a layer the other
+// collides with.
+func (l ColliderLayers) interacts(o ColliderLayers) bool {
+	return l.Layers&o.Mask != 0 && o.Layers&l.Mask != 0
+}
+
+// LayeredCollisionEntity is an entity whose colliders are on collision layers.
+type LayeredCollisionEntity interface {
+	CollisionEntity
+
+	// GetColliderLayers returns the layers of each of the colliders
+	// returned by GetColliders.
+	GetColliderLayers() []ColliderLayers
+}
+
+// collisionHandler responds to a collision between two entities. The A side
+// of the event is on the first layer of the pair the handler is for.
+type collisionHandler func(s *GameScene, event *CollisionEvent)
+
+// layerPair is a pair of single layers that collided.
+type layerPair struct {
+	a, b CollisionLayer
+}
+
+// collisionHandlers are the responses to collisions between the layers.
+// Collisions between layers without a handler are detected but ignored.
+var collisionHandlers = map[layerPair]collisionHandler{
+	{layerShip, layerWall}:    (*GameScene).onShipCrashed,
+	{layerShip, layerHazard}:  (*GameScene).onShipCrashed,
+	{layerShip, layerTrigger}: (*GameScene).onShipInTrigger,
+	{layerShip, layerPickup}:  (*GameScene).onShipPickup,
+}
+
+// collisionBody is an entity taking part in the collision pass.
+type collisionBody struct {
+	entity    scene.Entity
+	colliders []glider.Collider
+	layers    []ColliderLayers
+
+	// all is every layer and every mask of the colliders so that entities
+	// that can't collide are skipped quickly.
+	all ColliderLayers
+}
+
+// collisionBodies returns the entities with colliders ordered by their id.
+func (s *GameScene) collisionBodies() []*collisionBody {
+	var bodies []*collisionBody
+	s.BasicSceneManager.MapEntities(func(id uint64, e scene.Entity) {
+		le, okay := e.(LayeredCollisionEntity)
+		if !okay {
+			return
+		}
+		colliders := le.GetColliders()
+		layers := le.GetColliderLayers()
+		if len(colliders) == 0 || len(colliders) != len(layers) {
+			return
+		}
+		body := &collisionBody{entity: e, colliders: colliders, layers: layers}
+		for _, l := range layers {
+			body.all.Layers |= l.Layers
+			body.all.Mask |= l.Mask
+		}
+		bodies = append(bodies, body)
+	})
+	sort.Slice(bodies, func(i, j int) bool {
+		return bodies[i].entity.GetID() < bodies[j].entity.GetID()
+	})
+	return bodies
+}
+
+// layerPairs returns the pairs of single layers of two colliders that
+// collide with each other, in the order of collisionLayers.
+func layerPairs(la, lb ColliderLayers) []layerPair {
+	var pairs []layerPair
+	for _, layerA := range collisionLayers {
+		if la.Layers&layerA == 0 || lb.Mask&layerA == 0 {
+			continue
+		}
+		for _, layerB := range collisionLayers {
+			if lb.Layers&layerB != 0 && la.Mask&layerB != 0 {
+				pairs = append(pairs, layerPair{layerA, layerB})
+			}
+		}
+	}
+	return pairs
+}
+
+// checkCollisions tests every pair of entities on layers that collide with
+// each other and sends an event for each pair of colliders that hit to the
+// handlers for their layers and then publishes it. Pairs are
+// handled in order of their entity ids so that the results don't depend on
+// the order the scene keeps the entities in.
+func (s *GameScene) checkCollisions() {
+	bodies := s.collisionBodies()
+	for i, a := range bodies {
+		for _, b := range bodies[i+1:] {
+			if !a.all.interacts(b.all) {
+				continue
+			}
+			events := findCollisions(a, b)
+			if len(events) == 0 {
+				continue
+			}
+
+			// triggers aren't solid so only the hits between other layers
+			// need to be confirmed by the narrow phase, which is only run
+			// once for the pair of entities.
+			confirmed, checked := false, false
+			for _, event := range events {
+				if !event.IsTrigger() {
+					if !checked {
+						confirmed, checked = s.confirmCollision(a.entity, b.entity), true
+					}
+					if !confirmed {
+						continue
+					}
+				}
+				s.dispatchCollision(event)
+			}
+		}
+	}
+}
+
+// dispatchCollision calls the handlers for the layers of the colliders in
+// the event, each at most once, and then publishes the event.
+func (s *GameScene) dispatchCollision(event *CollisionEvent) {
+	called := make(map[layerPair]bool)
+	for _, pair := range layerPairs(event.LayersA, event.LayersB) {
+		if handler, okay := collisionHandlers[pair]; okay && !called[pair] {
+			called[pair] = true
+			handler(s, event)
+		}
+		reversed := layerPair{pair.b, pair.a}
+		if handler, okay := collisionHandlers[reversed]; okay && !called[reversed] {
+			called[reversed] = true
+			handler(s, event.Reversed())
+		}
+	}
+
+	s.Events.PublishCollision(event)
+}
+
+// onShipCrashed handles the ship hitting a wall or a hazard. If several
+// entities are hit in the same frame the one with the lowest id is the one
+// the ship crashed into.
+func (s *GameScene) onShipCrashed(event *CollisionEvent) {
+	if event.A.GetID() != s.shipEntity.ID {
+		return
+	}
+	if s.crash == nil || event.B.GetID() < s.crash.B.GetID() {
+		s.crash = event
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	component "github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/glider"
+)
+
+// testTaggedEntity is an entity with a single sphere collider that has the tags.
+type testTaggedEntity struct {
+	*VisibleEntity
+}
+
+func (e *testTaggedEntity) GetColliders() []glider.Collider {
+	return e.CoarseColliders
+}
+
+// addTaggedEntity adds an entity with a sphere collider of the radius and
+// tags to the scene at the location.
+func addTaggedEntity(s *GameScene, name string, location mgl.Vec3, radius float32, tags ...string) *testTaggedEntity {
+	e := &testTaggedEntity{NewVisibleEntity()}
+	e.Name = name
+	e.CreateCollidersFromComponent(&component.Component{
+		Collisions: []*component.CollisionRef{
+			{Type: component.ColliderTypeSphere, Radius: radius, Tags: tags},
+		},
+	})
+	e.SetLocation(location)
+	s.AddEntity(e)
+	return e
+}
+
+// newCollisionTestScene returns a headless scene with nothing touching the ship.
+func newCollisionTestScene(t *testing.T) *GameScene {
+	s := newTestScene(t)
+	s.UseFixedSeed = true
+	s.FixedSeed = 1
+	err := s.SetupScene()
+	if err != nil {
+		t.Fatalf("failed to set up the scene: %v", err)
+	}
+	s.checkCollisions()
+	if s.crash != nil || len(s.pickupsTouched) != 0 || len(s.triggersTouched) != 0 {
+		t.Fatalf("the ship is touching something at the start of the run")
+	}
+	return s
+}
+
+func TestParseColliderLayers(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		fallback CollisionLayer
+		layers   ColliderLayers
+		err      bool
+	}{
+		{nil, layerHazard, defaultColliderLayers(layerHazard), false},
+		{[]string{"pickup", "bonus:50"}, layerHazard, defaultColliderLayers(layerPickup), false},
+		{[]string{"wall", "trigger"}, layerHazard, defaultColliderLayers(layerWall | layerTrigger), false},
+		{[]string{"hazard", "collides:ship"}, layerWall, ColliderLayers{Layers: layerHazard, Mask: layerShip}, false},
+		{[]string{"collides:projectile", "collides:ship"}, layerWall,
+			ColliderLayers{Layers: layerWall, Mask: layerProjectile | layerShip}, false},
+		{[]string{"hazard", "collides:ghosts", "collides:ship"}, layerWall,
+			ColliderLayers{Layers: layerHazard, Mask: layerShip}, true},
+	}
+
+	for _, test := range tests {
+		layers, err := parseColliderLayers(test.tags, test.fallback)
+		if layers != test.layers {
+			t.Errorf("%v: expected %+v, got %+v", test.tags, test.layers, layers)
+		}
+		if (err != nil) != test.err {
+			t.Errorf("%v: expected an error to be %v, got %v", test.tags, test.err, err)
+		}
+	}
+}
+
+func TestShipPickupDispatch(t *testing.T) {
+	s := newCollisionTestScene(t)
+	pickup := addTaggedEntity(s, "Pickup", s.shipEntity.GetLocation(), 0.5, "pickup", "bonus:50")
+
+	var collected []*PickupEvent
+	s.Events.OnPickupCollected(func(e *PickupEvent) { collected = append(collected, e) })
+	collisions := 0
+	s.Events.OnCollision(func(e *CollisionEvent) { collisions++ })
+
+	s.checkCollisions()
+	if s.crash != nil {
+		t.Errorf("the pickup reached the crash handler")
+	}
+	if _, okay := s.pickupsTouched[pickup.GetID()]; !okay {
+		t.Errorf("the pickup didn't reach the pickup handler")
+	}
+	if collisions != 1 {
+		t.Errorf("expected the collision to be published once, got %d", collisions)
+	}
+
+	s.collectPickups()
+	if len(collected) != 1 || collected[0].Entity != pickup || collected[0].Bonus != 50 {
+		t.Errorf("expected the pickup to be collected with its bonus, got %+v", collected)
+	}
+
+	// the same overlap with a hazard is a crash
+	hazard := addTaggedEntity(s, "Hazard", s.shipEntity.GetLocation(), 0.5, "hazard")
+	s.checkCollisions()
+	if s.crash == nil || s.crash.B != hazard {
+		t.Errorf("the hazard didn't reach the crash handler")
+	}
+}
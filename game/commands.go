@@ -0,0 +1,105 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// EntityCommands buffers the changes to the entities of a scene that are
+// requested while the systems update or the entities are being mapped over,
+// when changing the scene right away isn't safe. The scene applies them in
+// the order they were requested once the systems have updated and again at
+// the end of the simulated frame.
+type EntityCommands struct {
+	commands []entityCommand
+}
+
+type entityCommandKind int
+
+const (
+	commandSpawn entityCommandKind = iota
+	commandDespawn
+	commandChange
+)
+
+// entityCommand is a single change requested for an entity.
+type entityCommand struct {
+	kind   entityCommandKind
+	entity scene.Entity
+	change func(e scene.Entity)
+}
+
+// Spawn requests the entity be added to the scene.
+func (c *EntityCommands) Spawn(e scene.Entity) {
+	c.commands = append(c.commands, entityCommand{kind: commandSpawn, entity: e})
+}
+
+// Despawn requests the entity be removed from the scene. Requesting it more
+// than once before the commands are applied only removes it once.
+func (c *EntityCommands) Despawn(e scene.Entity) {
+	c.commands = append(c.commands, entityCommand{kind: commandDespawn, entity: e})
+}
+
+// Change requests the function be called with the entity, such as to swap
+// its renderable or colliders. Changes to an entity that's despawned before
+// the change is applied are dropped.
+func (c *EntityCommands) Change(e scene.Entity, change func(e scene.Entity)) {
+	c.commands = append(c.commands, entityCommand{kind: commandChange, entity: e, change: change})
+}
+
+// Pending returns the number of commands waiting to be applied.
+func (c *EntityCommands) Pending() int {
+	return len(c.commands)
+}
+
+// discard drops the commands waiting to be applied.
+func (c *EntityCommands) discard() {
+	for i := range c.commands {
+		c.commands[i] = entityCommand{}
+	}
+	c.commands = c.commands[:0]
+}
+
+// applyCommands applies the buffered entity commands in the order they were
+// requested. Commands requested by the handlers of the spawn and despawn
+// events while applying them are applied as well. Despawning an entity
+// despawns the entities attached to it too.
+func (s *GameScene) applyCommands() {
+	// despawned are the entities removed by these commands and not spawned again.
+	var despawned map[uint64]bool
+	for i := 0; i < len(s.Commands.commands); i++ {
+		cmd := s.Commands.commands[i]
+		id := cmd.entity.GetID()
+		switch cmd.kind {
+		case commandSpawn:
+			delete(despawned, id)
+			s.AddEntity(cmd.entity)
+		case commandDespawn:
+			if despawned[id] {
+				continue
+			}
+			if despawned == nil {
+				despawned = make(map[uint64]bool)
+			}
+			despawned[id] = true
+			s.RemoveEntity(cmd.entity)
+
+			// the entities attached to it go with it and it stops
+			// following the entity it was attached to.
+			if se, okay := cmd.entity.(SpawnableEntity); okay {
+				ve := se.GetVisibleEntity()
+				for _, child := range ve.GetChildren() {
+					s.Commands.Despawn(child)
+				}
+				ve.Detach()
+			}
+		case commandChange:
+			if !despawned[id] {
+				cmd.change(cmd.entity)
+			}
+		}
+	}
+	s.Commands.discard()
+}
@@ -0,0 +1,174 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// countEntities returns the number of entities in the scene.
+func countEntities(s *GameScene) int {
+	count := 0
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		count++
+	})
+	return count
+}
+
+// spawnTestWalls spawns count walls in the scene.
+func spawnTestWalls(t *testing.T, s *GameScene, count int) []SpawnableEntity {
+	var walls []SpawnableEntity
+	for i := 0; i < count; i++ {
+		wall, err := s.Spawn(entityKindWall, Transform{}, SpawnOptions{})
+		if err != nil {
+			t.Fatalf("failed to spawn a wall: %v", err)
+		}
+		walls = append(walls, wall)
+	}
+	return walls
+}
+
+func TestDespawnWhileMapping(t *testing.T) {
+	s := newTestScene(t)
+	spawnTestWalls(t, s, 6)
+
+	visited := 0
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		visited++
+		if id%2 == 0 {
+			s.Commands.Despawn(e)
+		}
+		if countEntities(s) != 6 {
+			t.Fatalf("the scene changed while it was being mapped over")
+		}
+	})
+	if visited != 6 {
+		t.Errorf("visited %d entities, expected 6", visited)
+	}
+
+	s.applyCommands()
+	if count := countEntities(s); count != 3 {
+		t.Errorf("expected 3 entities left, got %d", count)
+	}
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		if id%2 == 0 {
+			t.Errorf("entity %d wasn't despawned", id)
+		}
+	})
+	if s.Commands.Pending() != 0 {
+		t.Errorf("expected no pending commands, got %d", s.Commands.Pending())
+	}
+}
+
+func TestDespawnThenRespawn(t *testing.T) {
+	s := newTestScene(t)
+	wall := spawnTestWalls(t, s, 1)[0]
+
+	var events []string
+	s.Events.OnEntitySpawned(func(e *EntityEvent) { events = append(events, "spawned") })
+	s.Events.OnEntityDespawned(func(e *EntityEvent) { events = append(events, "despawned") })
+
+	changed := 0
+	s.Commands.Despawn(wall)
+	s.Commands.Change(wall, func(e scene.Entity) { changed++ })
+	s.Commands.Spawn(wall)
+	s.Commands.Change(wall, func(e scene.Entity) { changed++ })
+	s.applyCommands()
+
+	if findEntity(s, wall.GetID()) != scene.Entity(wall) {
+		t.Errorf("the respawned wall isn't in the scene")
+	}
+	if len(events) != 2 || events[0] != "despawned" || events[1] != "spawned" {
+		t.Errorf("expected a despawn then a spawn, got %v", events)
+	}
+	if changed != 1 {
+		t.Errorf("expected only the change after the respawn to be applied, got %d", changed)
+	}
+}
+
+func TestDuplicateDespawns(t *testing.T) {
+	s := newTestScene(t)
+	walls := spawnTestWalls(t, s, 3)
+
+	// attach a wall to another so that it's despawned with it as well as on its own
+	parent, child := walls[0], walls[1]
+	err := parent.GetVisibleEntity().Attach(child, Transform{})
+	if err != nil {
+		t.Fatalf("failed to attach the wall: %v", err)
+	}
+
+	despawns := make(map[uint64]int)
+	s.Events.OnEntityDespawned(func(e *EntityEvent) { despawns[e.Entity.GetID()]++ })
+
+	s.Commands.Despawn(parent)
+	s.Commands.Despawn(child)
+	s.Commands.Despawn(parent)
+	s.Commands.Despawn(child)
+	s.applyCommands()
+
+	if despawns[parent.GetID()] != 1 || despawns[child.GetID()] != 1 {
+		t.Errorf("expected each wall to be despawned once, got %v", despawns)
+	}
+	if len(despawns) != 2 {
+		t.Errorf("expected only the two walls to be despawned, got %v", despawns)
+	}
+	if count := countEntities(s); count != 1 {
+		t.Errorf("expected 1 entity left, got %d", count)
+	}
+	if child.GetVisibleEntity().GetParent() != nil {
+		t.Errorf("the despawned child is still attached")
+	}
+}
+
+// restartSystem asks for a new run during its update and records what the
+// scene looked like right after asking.
+type restartSystem struct {
+	s                *GameScene
+	restart          bool
+	shipDuringUpdate *ShipEntity
+}
+
+func (r *restartSystem) Update(frameDelta float32) {
+	if r.restart {
+		r.restart = false
+		r.s.Events.PublishRestartRequested()
+		r.shipDuringUpdate = r.s.shipEntity
+	}
+}
+
+func (r *restartSystem) OnAddEntity(newEntity scene.Entity)    {}
+func (r *restartSystem) OnRemoveEntity(oldEntity scene.Entity) {}
+func (r *restartSystem) GetRequestedPriority() float32         { return 0.0 }
+func (r *restartSystem) GetName() string                       { return "RestartSystem" }
+
+func TestRestartWaitsForTheUpdate(t *testing.T) {
+	s := newTestScene(t)
+	s.UseFixedSeed = true
+	s.FixedSeed = 1
+	err := s.SetupScene()
+	if err != nil {
+		t.Fatalf("failed to set up the scene: %v", err)
+	}
+	system := &restartSystem{s: s, restart: true}
+	s.AddSystem(system)
+
+	oldShip := s.shipEntity
+	s.setGameState(GameStatePlayerDied)
+	s.Update(1.0 / 60.0)
+
+	if system.shipDuringUpdate != oldShip {
+		t.Errorf("the scene was reset while the systems were updating")
+	}
+	if s.shipEntity == oldShip || findEntity(s, oldShip.GetID()) != nil {
+		t.Errorf("the scene wasn't reset after the update")
+	}
+	if s.State() != GameStatePlaying {
+		t.Errorf("expected the new run to be playing, got state %d", s.State())
+	}
+	if s.restartPending {
+		t.Errorf("the restart is still pending")
+	}
+}
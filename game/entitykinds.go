@@ -0,0 +1,204 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"sync"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	component "github.com/tbogdala/fizzle/component"
+	scene "github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+// The names of the kinds of entities the game spawns.
+const (
+	entityKindWall  = "wall"
+	entityKindBomb  = "bomb"
+	entityKindShip  = "ship"
+	entityKindGhost = "ghost"
+)
+
+// SpawnableEntity is an entity that can be created by an EntityKind or
+// attached to another entity. All of them are a VisibleEntity at heart.
+type SpawnableEntity interface {
+	scene.Entity
+	GetVisibleEntity() *VisibleEntity
+}
+
+// EntityKind is a kind of entity that can be spawned by name.
+type EntityKind struct {
+	// ComponentName is the component the entities are created from.
+	ComponentName string
+
+	// New returns a new entity of the kind before it's set up from the
+	// component c.
+	New func(s *GameScene, c *component.Component) (SpawnableEntity, error)
+
+	// NoColliders spawns the entities without the colliders of the
+	// component, such as for the ghost that can't hit anything.
+	NoColliders bool
+
+	// LogSpawns writes the spawning of each entity to the run log.
+	LogSpawns bool
+}
+
+// SpawnOptions override how an entity is spawned. The zero value spawns it
+// the way its kind is registered.
+type SpawnOptions struct {
+	// Name is the name of the entity. It's the kind and id if it's empty.
+	Name string
+
+	// ComponentName replaces the component of the kind, such as for a
+	// variation of a wall segment.
+	ComponentName string
+
+	// Parent is the entity to attach the new one to, if any, in which case
+	// the transform is relative to it.
+	Parent SpawnableEntity
+
+	// Setup is called with the entity before it's added to the scene.
+	Setup func(e SpawnableEntity)
+
+	// Deferred spawns the entity through the scene's Commands, for when
+	// it's spawned while the entities are being iterated over.
+	Deferred bool
+}
+
+// entityKindsLock guards entityKinds so that kinds can be registered while
+// scenes are spawning entities.
+var entityKindsLock sync.RWMutex
+
+// entityKinds are the kinds of entities that can be spawned by name.
+var entityKinds = map[string]*EntityKind{
+	entityKindWall: {
+		ComponentName: gridComponentName,
+		New: func(s *GameScene, c *component.Component) (SpawnableEntity, error) {
+			return NewWallSetEntity(), nil
+		},
+		LogSpawns: true,
+	},
+	entityKindBomb: {
+		ComponentName: bombComponentName,
+		New: func(s *GameScene, c *component.Component) (SpawnableEntity, error) {
+			bomb := NewBombEntity(s.rng)
+			bomb.SetMaxSpeed()
+			return bomb, nil
+		},
+		LogSpawns: true,
+	},
+	entityKindShip: {
+		ComponentName: shipComponentName,
+		New: func(s *GameScene, c *component.Component) (SpawnableEntity, error) {
+			ship := NewShipEntity()
+			err := ship.Handling.LoadFromComponent(c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the ship handling: %v", err)
+			}
+			ship.currentShipSpeed = mgl.Vec3{0.0, 0.0, ship.Handling.CruiseSpeed}
+			return ship, nil
+		},
+	},
+	entityKindGhost: {
+		ComponentName: shipComponentName,
+		New: func(s *GameScene, c *component.Component) (SpawnableEntity, error) {
+			if s.Ghost == nil {
+				return nil, fmt.Errorf("there is no ghost track to follow")
+			}
+			return NewGhostEntity(s.Ghost), nil
+		},
+		NoColliders: true,
+	},
+}
+
+// RegisterEntityKind adds a kind of entity that can be spawned by name, such
+// as for a mod. The kind's name can't already be taken, including by the
+// kinds of the game. It's safe to call while scenes are spawning entities.
+func RegisterEntityKind(name string, kind EntityKind) error {
+	if kind.New == nil {
+		return fmt.Errorf("the entity kind %s has no constructor", name)
+	}
+
+	entityKindsLock.Lock()
+	defer entityKindsLock.Unlock()
+	if _, okay := entityKinds[name]; okay {
+		return fmt.Errorf("the entity kind %s is already registered", name)
+	}
+	entityKinds[name] = &kind
+	return nil
+}
+
+// lookupEntityKind returns the registered kind of entity with the name.
+func lookupEntityKind(name string) (*EntityKind, bool) {
+	entityKindsLock.RLock()
+	defer entityKindsLock.RUnlock()
+	k, okay := entityKinds[name]
+	return k, okay
+}
+
+// Spawn creates an entity of the kind at the transform, sets it up from its
+// component and adds it to the scene.
+func (s *GameScene) Spawn(kind string, t Transform, opts SpawnOptions) (SpawnableEntity, error) {
+	k, okay := lookupEntityKind(kind)
+	if !okay {
+		return nil, fmt.Errorf("unknown entity kind %s", kind)
+	}
+	componentName := k.ComponentName
+	if opts.ComponentName != "" {
+		componentName = opts.ComponentName
+	}
+	c := s.getComponent(componentName)
+	if c == nil {
+		return nil, fmt.Errorf("failed to spawn a %s: no %s component is loaded", kind, componentName)
+	}
+
+	e, err := k.New(s, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to spawn a %s: %v", kind, err)
+	}
+	ve := e.GetVisibleEntity()
+	ve.ID = s.GetNextID()
+	ve.Name = opts.Name
+	if ve.Name == "" {
+		ve.Name = fmt.Sprintf("%s_%d", kind, ve.ID)
+	}
+	ve.ComponentName = componentName
+	if setter, okay := e.(RenderableSetter); okay {
+		setter.SetRenderable(s.getRenderableInstance(c))
+	}
+
+	// the entity is placed before its colliders are created so they're
+	// created where it is.
+	if opts.Parent != nil {
+		err = opts.Parent.GetVisibleEntity().Attach(e, t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to spawn a %s: %v", kind, err)
+		}
+	} else {
+		ve.SetLocation(t.Location)
+		if t.Orientation != (mgl.Quat{}) {
+			ve.SetOrientation(t.Orientation)
+		}
+	}
+	if !k.NoColliders {
+		ve.CreateCollidersFromComponent(c)
+	}
+
+	if opts.Setup != nil {
+		opts.Setup(e)
+	}
+	// the entity itself is added, not its VisibleEntity, so that the scene
+	// and the systems see the interfaces of its kind.
+	if opts.Deferred {
+		s.Commands.Spawn(e)
+	} else {
+		s.AddEntity(e)
+	}
+	if k.LogSpawns {
+		s.logEntityEvent(telemetry.TypeSpawn, e, e.GetLocation())
+	}
+	return e, nil
+}
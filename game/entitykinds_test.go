@@ -0,0 +1,163 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	component "github.com/tbogdala/fizzle/component"
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// newTestScene returns a headless scene with the test components loaded and
+// nothing spawned in it yet. The test components have the colliders of the
+// game's components without their models, which are stored with git-lfs.
+func newTestScene(t *testing.T) *GameScene {
+	AssetRoots = []string{filepath.Join("testdata", DefaultAssetRoot)}
+	s := NewHeadlessGameScene()
+	err := s.loadHeadlessComponents()
+	if err != nil {
+		t.Fatalf("failed to load the components: %v", err)
+	}
+	s.rng = rand.New(rand.NewSource(1))
+	return s
+}
+
+// findEntity returns the entity with the id in the scene or nil.
+func findEntity(s *GameScene, id uint64) scene.Entity {
+	var found scene.Entity
+	s.MapEntities(func(entityID uint64, e scene.Entity) {
+		if entityID == id {
+			found = e
+		}
+	})
+	return found
+}
+
+func TestSpawnAddsConcreteEntities(t *testing.T) {
+	s := newTestScene(t)
+	s.Ghost = NewGhostTrack(1, GameModeNormal)
+
+	tests := map[string]struct {
+		entityType reflect.Type
+		check      func(e scene.Entity) bool
+	}{
+		entityKindWall: {reflect.TypeOf(&WallSetEntity{}), func(e scene.Entity) bool {
+			_, okay := e.(ScrollableEntity)
+			return okay
+		}},
+		entityKindBomb: {reflect.TypeOf(&BombEntity{}), func(e scene.Entity) bool {
+			_, scrollable := e.(ScrollableEntity)
+			_, moving := e.(MovingEntity)
+			return scrollable && moving
+		}},
+		entityKindShip: {reflect.TypeOf(&ShipEntity{}), func(e scene.Entity) bool {
+			_, okay := e.(MovingEntity)
+			return okay
+		}},
+		entityKindGhost: {reflect.TypeOf(&GhostEntity{}), func(e scene.Entity) bool {
+			translucent, okay := e.(TranslucentEntity)
+			return okay && translucent.IsTranslucent()
+		}},
+	}
+
+	for kind := range entityKinds {
+		test, okay := tests[kind]
+		if !okay {
+			t.Errorf("the entity kind %s isn't covered by the test", kind)
+			continue
+		}
+
+		for _, deferred := range []bool{false, true} {
+			e, err := s.Spawn(kind, Transform{}, SpawnOptions{Deferred: deferred})
+			if err != nil {
+				t.Errorf("failed to spawn a %s: %v", kind, err)
+				continue
+			}
+			s.applyCommands()
+
+			found := findEntity(s, e.GetID())
+			if found != scene.Entity(e) {
+				t.Errorf("the scene holds %T for the spawned %s (deferred %v), not the %T spawned", found, kind, deferred, e)
+				continue
+			}
+			if reflect.TypeOf(found) != test.entityType {
+				t.Errorf("a %s is a %T, expected %v", kind, found, test.entityType)
+			}
+			if !test.check(found) {
+				t.Errorf("a %s (%T) is missing the interfaces of its kind", kind, found)
+			}
+		}
+	}
+}
+
+func TestSpawnShipForController(t *testing.T) {
+	s := newTestScene(t)
+	controller := NewShipController(s)
+	s.AddSystem(controller)
+
+	ship, err := s.Spawn(entityKindShip, Transform{}, SpawnOptions{Name: PlayerShipEntityName})
+	if err != nil {
+		t.Fatalf("failed to spawn the ship: %v", err)
+	}
+	if controller.playerShipEntity != ship {
+		t.Errorf("the ship controller didn't pick up the spawned ship")
+	}
+}
+
+func TestRegisterEntityKind(t *testing.T) {
+	newWall := func(s *GameScene, c *component.Component) (SpawnableEntity, error) {
+		return NewWallSetEntity(), nil
+	}
+	builtIn, _ := lookupEntityKind(entityKindBomb)
+
+	err := RegisterEntityKind(entityKindBomb, EntityKind{ComponentName: gridComponentName, New: newWall})
+	if err == nil {
+		t.Errorf("expected registering the bomb kind again to fail")
+	}
+	if k, _ := lookupEntityKind(entityKindBomb); k != builtIn {
+		t.Errorf("the built in bomb kind was replaced")
+	}
+	err = RegisterEntityKind("test/nonew", EntityKind{ComponentName: gridComponentName})
+	if err == nil {
+		t.Errorf("expected a kind without a constructor to fail")
+	}
+
+	// registering kinds while a scene spawns doesn't race
+	s := newTestScene(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			RegisterEntityKind(fmt.Sprintf("test/wall%d", i), EntityKind{ComponentName: gridComponentName, New: newWall})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		_, err := s.Spawn(entityKindWall, Transform{}, SpawnOptions{})
+		if err != nil {
+			t.Errorf("failed to spawn a wall: %v", err)
+		}
+	}
+	wg.Wait()
+
+	wall, err := s.Spawn("test/wall19", Transform{}, SpawnOptions{})
+	if err != nil {
+		t.Fatalf("failed to spawn the registered kind: %v", err)
+	}
+	if _, okay := wall.(*WallSetEntity); !okay {
+		t.Errorf("the registered kind spawned a %T", wall)
+	}
+
+	entityKindsLock.Lock()
+	for i := 0; i < 20; i++ {
+		delete(entityKinds, fmt.Sprintf("test/wall%d", i))
+	}
+	entityKindsLock.Unlock()
+}
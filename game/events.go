@@ -0,0 +1,266 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// EventBus delivers the gameplay events of a scene to the handlers that
+// subscribed to them so that gameplay code and systems don't need to know
+// about each other. Each event has its own type and its own pair of
+// methods: On* subscribes a handler and Publish* calls the handlers, in the
+// order they subscribed, before returning.
+type EventBus struct {
+	playerDied      []func(*PlayerDiedEvent)
+	runStarted      []func(*RunStartedEvent)
+	entitySpawned   []func(*EntityEvent)
+	entityDespawned []func(*EntityEvent)
+	pickupCollected []func(*PickupEvent)
+	stateChanged    []func(*StateChangedEvent)
+	collision       []func(*CollisionEvent)
+	trigger         []func(*TriggerEvent)
+	toast           []func(*ToastEvent)
+	assetErrors     []func(*AssetErrorsEvent)
+	mainMenu        []func()
+	play            []func()
+	restart         []func()
+	quit            []func()
+}
+
+// EventSubscriber is a system that subscribes to the events of the scene
+// when it's added to it.
+type EventSubscriber interface {
+	SubscribeEvents(events *EventBus)
+}
+
+// PlayerDiedEvent is published when the ship crashes, after the run has
+// been recorded.
+type PlayerDiedEvent struct {
+	// Cause is the entity the ship crashed into.
+	Cause scene.Entity
+
+	Score    int64
+	Distance float64
+
+	// Rank is the rank of the run in the high score table or -1 if it
+	// didn't make the table.
+	Rank int
+}
+
+// RunStartedEvent is published when a new run is set up.
+type RunStartedEvent struct {
+	Seed int64
+	Mode string
+
+	// HasGhost is true if a ghost is being raced in the run.
+	HasGhost bool
+}
+
+// EntityEvent is published when an entity is added to or removed from the scene.
+type EntityEvent struct {
+	Entity scene.Entity
+}
+
+// PickupEvent is published when the ship collects a pickup.
+type PickupEvent struct {
+	// Entity is the pickup and Tags are the tags of the collider that was hit.
+	Entity scene.Entity
+	Tags   []string
+
+	// Bonus is what the pickup added to the score.
+	Bonus int64
+}
+
+// StateChangedEvent is published when the game state changes.
+type StateChangedEvent struct {
+	From, To int
+}
+
+// ToastEvent is a notification to show without interrupting the game.
+type ToastEvent struct {
+	Title string
+	Text  string
+}
+
+// AssetErrorsEvent is published when the assets fail to reload, and with no
+// Lines once they load again.
+type AssetErrorsEvent struct {
+	Title string
+	Lines []string
+}
+
+// OnPlayerDied subscribes a handler to the ship crashing.
+func (b *EventBus) OnPlayerDied(handler func(*PlayerDiedEvent)) {
+	b.playerDied = append(b.playerDied, handler)
+}
+
+// PublishPlayerDied calls the handlers for the ship crashing.
+func (b *EventBus) PublishPlayerDied(e *PlayerDiedEvent) {
+	for _, handler := range b.playerDied {
+		handler(e)
+	}
+}
+
+// OnRunStarted subscribes a handler to new runs being set up.
+func (b *EventBus) OnRunStarted(handler func(*RunStartedEvent)) {
+	b.runStarted = append(b.runStarted, handler)
+}
+
+// PublishRunStarted calls the handlers for a new run being set up.
+func (b *EventBus) PublishRunStarted(e *RunStartedEvent) {
+	for _, handler := range b.runStarted {
+		handler(e)
+	}
+}
+
+// OnEntitySpawned subscribes a handler to entities being added to the scene.
+func (b *EventBus) OnEntitySpawned(handler func(*EntityEvent)) {
+	b.entitySpawned = append(b.entitySpawned, handler)
+}
+
+// PublishEntitySpawned calls the handlers for an entity added to the scene.
+func (b *EventBus) PublishEntitySpawned(e *EntityEvent) {
+	for _, handler := range b.entitySpawned {
+		handler(e)
+	}
+}
+
+// OnEntityDespawned subscribes a handler to entities being removed from the scene.
+func (b *EventBus) OnEntityDespawned(handler func(*EntityEvent)) {
+	b.entityDespawned = append(b.entityDespawned, handler)
+}
+
+// PublishEntityDespawned calls the handlers for an entity removed from the scene.
+func (b *EventBus) PublishEntityDespawned(e *EntityEvent) {
+	for _, handler := range b.entityDespawned {
+		handler(e)
+	}
+}
+
+// OnPickupCollected subscribes a handler to the ship collecting pickups.
+func (b *EventBus) OnPickupCollected(handler func(*PickupEvent)) {
+	b.pickupCollected = append(b.pickupCollected, handler)
+}
+
+// PublishPickupCollected calls the handlers for the ship collecting a pickup.
+func (b *EventBus) PublishPickupCollected(e *PickupEvent) {
+	for _, handler := range b.pickupCollected {
+		handler(e)
+	}
+}
+
+// OnStateChanged subscribes a handler to changes of the game state.
+func (b *EventBus) OnStateChanged(handler func(*StateChangedEvent)) {
+	b.stateChanged = append(b.stateChanged, handler)
+}
+
+// PublishStateChanged calls the handlers for a change of the game state.
+func (b *EventBus) PublishStateChanged(e *StateChangedEvent) {
+	for _, handler := range b.stateChanged {
+		handler(e)
+	}
+}
+
+// OnCollision subscribes a handler to every collision, such as so that
+// effects and sounds can react to the ship's hits. The handlers for the
+// collision layers have already run when it's called.
+func (b *EventBus) OnCollision(handler func(*CollisionEvent)) {
+	b.collision = append(b.collision, handler)
+}
+
+// PublishCollision calls the handlers for a collision.
+func (b *EventBus) PublishCollision(e *CollisionEvent) {
+	for _, handler := range b.collision {
+		handler(e)
+	}
+}
+
+// OnTrigger subscribes a handler to the ship entering and leaving triggers,
+// such as to start the scripted event named by a zone.
+func (b *EventBus) OnTrigger(handler func(*TriggerEvent)) {
+	b.trigger = append(b.trigger, handler)
+}
+
+// PublishTrigger calls the handlers for the ship entering or leaving a trigger.
+func (b *EventBus) PublishTrigger(e *TriggerEvent) {
+	for _, handler := range b.trigger {
+		handler(e)
+	}
+}
+
+// OnToast subscribes a handler that shows notifications.
+func (b *EventBus) OnToast(handler func(*ToastEvent)) {
+	b.toast = append(b.toast, handler)
+}
+
+// PublishToast calls the handlers that show notifications.
+func (b *EventBus) PublishToast(e *ToastEvent) {
+	for _, handler := range b.toast {
+		handler(e)
+	}
+}
+
+// OnAssetErrors subscribes a handler that shows the errors from reloading assets.
+func (b *EventBus) OnAssetErrors(handler func(*AssetErrorsEvent)) {
+	b.assetErrors = append(b.assetErrors, handler)
+}
+
+// PublishAssetErrors calls the handlers that show the errors from reloading assets.
+func (b *EventBus) PublishAssetErrors(e *AssetErrorsEvent) {
+	for _, handler := range b.assetErrors {
+		handler(e)
+	}
+}
+
+// OnMainMenu subscribes a handler that shows the main menu.
+func (b *EventBus) OnMainMenu(handler func()) {
+	b.mainMenu = append(b.mainMenu, handler)
+}
+
+// PublishMainMenu calls the handlers that show the main menu. It returns
+// false if there are none, such as in VR, so the game can start playing.
+func (b *EventBus) PublishMainMenu() bool {
+	for _, handler := range b.mainMenu {
+		handler()
+	}
+	return len(b.mainMenu) > 0
+}
+
+// OnPlayRequested subscribes a handler to requests to start playing from
+// the main menu.
+func (b *EventBus) OnPlayRequested(handler func()) {
+	b.play = append(b.play, handler)
+}
+
+// PublishPlayRequested asks for the game to start playing.
+func (b *EventBus) PublishPlayRequested() {
+	for _, handler := range b.play {
+		handler()
+	}
+}
+
+// OnRestartRequested subscribes a handler to requests for a new run.
+func (b *EventBus) OnRestartRequested(handler func()) {
+	b.restart = append(b.restart, handler)
+}
+
+// PublishRestartRequested asks for a new run, such as from a menu.
+func (b *EventBus) PublishRestartRequested() {
+	for _, handler := range b.restart {
+		handler()
+	}
+}
+
+// OnQuitRequested subscribes a handler to requests to quit the game.
+func (b *EventBus) OnQuitRequested(handler func()) {
+	b.quit = append(b.quit, handler)
+}
+
+// PublishQuitRequested asks for the game to quit.
+func (b *EventBus) PublishQuitRequested() {
+	for _, handler := range b.quit {
+		handler()
+	}
+}
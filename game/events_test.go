@@ -0,0 +1,30 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+)
+
+func TestEventHandlerOrder(t *testing.T) {
+	var bus EventBus
+	var order []int
+	for i := 0; i < 5; i++ {
+		i := i
+		bus.OnToast(func(e *ToastEvent) { order = append(order, i) })
+	}
+
+	bus.PublishToast(&ToastEvent{Title: "ORDER"})
+	bus.PublishToast(&ToastEvent{Title: "AGAIN"})
+	expected := []int{0, 1, 2, 3, 4, 0, 1, 2, 3, 4}
+	if len(order) != len(expected) {
+		t.Fatalf("expected the handlers to run %d times, got %v", len(expected), order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("expected the handlers to run in the order they subscribed, got %v", order)
+			break
+		}
+	}
+}
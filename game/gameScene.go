@@ -0,0 +1,900 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package game implements the simulation of Infinigrid: the game scene with
+// its entities, spawning, collisions and scoring. It has no dependency on a
+// window or input devices so that runs can also be re-simulated headless from
+// their replays, such as by the leaderboard server to verify them.
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"time"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	fizzle "github.com/tbogdala/fizzle"
+	component "github.com/tbogdala/fizzle/component"
+	forward "github.com/tbogdala/fizzle/renderer/forward"
+	scene "github.com/tbogdala/fizzle/scene"
+	"github.com/tbogdala/glider"
+
+	"github.com/tbogdala/infinigrid/atomicfile"
+	"github.com/tbogdala/infinigrid/leaderboard"
+	"github.com/tbogdala/infinigrid/replay"
+	"github.com/tbogdala/infinigrid/telemetry"
+	"github.com/tbogdala/infinigrid/trimesh"
+)
+
+const (
+	PlayerEntityName     = "Player"
+	PlayerShipEntityName = "PlayerShip"
+
+	FloorSizeWidth = 20.0
+	playerSpawnY   = 5.0
+)
+
+var (
+	// playerRigOffset is where the player sits relative to the ship.
+	playerRigOffset = mgl.Vec3{0.0, 0.2, -0.25}
+)
+
+const (
+	GameStatePlaying    = 1
+	GameStatePlayerDied = 2
+	GameStatePaused     = 3
+	GameStateMainMenu   = 4
+)
+
+const (
+	GameModeNormal = "normal"
+	GameModeDaily  = "daily"
+)
+
+// GameScene is the main game scene that plays the current level.
+type GameScene struct {
+	// embed the basic scene manager
+	*scene.BasicSceneManager
+
+	// playerEntity is the cached reference to the player entity. will be added to
+	// BasicSceneManager side as well.
+	playerEntity *VisibleEntity
+
+	// shipEntity is the cached reference to the ship pawn.
+	shipEntity *ShipEntity
+
+	// renderSystem is the system that draws the scene.
+	renderSystem RenderSystem
+
+	components *component.Manager
+	textureMan *fizzle.TextureManager
+
+	// componentFiles maps the names of the loaded components to their files.
+	componentFiles map[string]string
+
+	// collisionMeshes are the narrow phase meshes of the components that use
+	// them, keyed by the component name.
+	collisionMeshes map[string]*trimesh.Mesh
+
+	// AssetWatcher, if set, is polled for changes to the assets which are
+	// then reloaded. It's only used in dev mode.
+	AssetWatcher *AssetWatcher
+
+	// TextureQuality is the texture tier the components are loaded with,
+	// such as TextureQualityHigh. Use SetTextureQuality to change it once
+	// the scene has been set up.
+	TextureQuality string
+
+	// headless scenes have no systems, renderables or textures and are used
+	// to re-simulate runs from their replays.
+	headless           bool
+	headlessComponents map[string]*component.Component
+
+	shaders           map[string]*fizzle.RenderShader
+	currentFrameDelta float32
+
+	currentGameTime        float64
+	distSinceLastGridSpawn float64
+	lastBombSpawn          float64
+	distanceTravelled      float64
+
+	spawnIntervalSec float64
+	maxToSpawn       int
+
+	// GameMode is the mode of play for the runs such as GameModeNormal.
+	GameMode string
+
+	// FixedSeed will be used to seed every run if UseFixedSeed is set.
+	FixedSeed    int64
+	UseFixedSeed bool
+
+	// PlayerName is the name stored with the runs in the high score table.
+	PlayerName string
+
+	// HighScores is the local high score table. Runs are added to it when
+	// the player dies if it is non-nil.
+	HighScores *HighScoreTable
+
+	// Leaderboard is the online leaderboard runs get submitted to if it's non-nil.
+	Leaderboard *LeaderboardSync
+
+	// seed is the seed for the current run and rng is the random number
+	// generator seeded with it; all random spawning uses rng so that a
+	// run can be played again from its seed.
+	seed int64
+	rng  *rand.Rand
+
+	// runReplay is the recording of the current run.
+	runReplay *replay.Replay
+
+	// runTrack is the trajectory of the ship during the current run.
+	runTrack *GhostTrack
+
+	// Ghost is the recorded run to race against if it's non-nil. Runs use
+	// its seed so that the bombs spawn the same way for both.
+	Ghost *GhostTrack
+
+	// RaceBest makes each new personal best the Ghost to race against.
+	RaceBest bool
+
+	// PersonalBest is the trajectory of the best run in the game mode, if
+	// there is one, and its replay is saved to PersonalBestFile.
+	PersonalBest     *GhostTrack
+	PersonalBestFile string
+
+	// Achievements tracks the progress towards the achievements if it's non-nil.
+	Achievements *AchievementTracker
+
+	// TelemetryDir is the directory an event log is written to for each
+	// run. No logs are written if it's empty.
+	TelemetryDir string
+	telemetry    runTelemetry
+
+	// ghostEntity is the ship following the Ghost track.
+	ghostEntity *GhostEntity
+	ghostDelta  float64
+
+	// simulatingFrame is true if the game was being played at the start of
+	// the frame. The whole frame is simulated, and recorded, based on it so
+	// that state changes part way through a frame don't break replays.
+	simulatingFrame bool
+
+	// crash is the collision of the ship with what it crashed into this
+	// frame, if anything.
+	crash *CollisionEvent
+
+	// pickupsTouched are the pickups the ship touched this frame by id.
+	pickupsTouched map[uint64]*CollisionEvent
+
+	// triggersInside are the triggers the ship was inside of last frame and
+	// triggersTouched the ones it's inside of this frame.
+	triggersInside  map[triggerKey]scene.Entity
+	triggersTouched map[triggerKey]scene.Entity
+
+	// bonusScore is the score from the rings and pickups of this run and
+	// checkpointsPassed the number of checkpoint gates.
+	bonusScore        int64
+	checkpointsPassed int
+
+	// deathCause is the name of the entity the ship collided with.
+	deathCause string
+
+	// lastRunRank is the rank of the last run in the high score table or
+	// -1 if it didn't make the table.
+	lastRunRank int
+
+	// Events is where the gameplay events of the scene are published.
+	Events EventBus
+
+	// Commands buffers the entities to spawn, despawn and change until the
+	// scene applies them.
+	Commands EntityCommands
+
+	// restartPending is set when a new run is requested, such as from a
+	// menu while the systems update, and the run is restarted once they're
+	// done where the commands are applied.
+	restartPending bool
+
+	gameState   int
+	ShouldClose bool
+}
+
+// ScrollableEntity is an entity that scrolls past the player while the game plays.
+// This should include things like wall sets and bombs.
+type ScrollableEntity interface {
+	// ScrollPastPlayer should move the entity with relation to the inverse
+	// of the player speed, adjusted for frame delta. gameTime is the time
+	// the current run has been played for.
+	ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32, gameTime float64)
+}
+
+// RenderSystem is implemented by the systems that draw the scene with a
+// forward renderer, such as the VR and non-VR render systems.
+type RenderSystem interface {
+	GetRenderer() *forward.ForwardRenderer
+}
+
+// CollisionEntity should be implemented for all entities that can collide with other objects.
+type CollisionEntity interface {
+	// GetColliders should return all of the coarse colliders for an entity.
+	GetColliders() []glider.Collider
+}
+
+// NewHeadlessGameScene creates a new game scene object that simulates the
+// game without any systems or graphics.
+func NewHeadlessGameScene() *GameScene {
+	gs := NewGameScene()
+	gs.headless = true
+	return gs
+}
+
+// NewGameScene creates a new game scene object
+func NewGameScene() *GameScene {
+	gs := new(GameScene)
+	gs.BasicSceneManager = scene.NewBasicSceneManager()
+	gs.shaders = make(map[string]*fizzle.RenderShader)
+
+	// starting difficulty
+	gs.spawnIntervalSec = 2.0
+	gs.maxToSpawn = 12
+
+	gs.GameMode = GameModeNormal
+	gs.TextureQuality = TextureQualityLow
+	gs.lastRunRank = -1
+	gs.triggersInside = make(map[triggerKey]scene.Entity)
+	gs.triggersTouched = make(map[triggerKey]scene.Entity)
+	gs.pickupsTouched = make(map[uint64]*CollisionEvent)
+
+	gs.Events.OnPlayRequested(func() { gs.setGameState(GameStatePlaying) })
+	gs.Events.OnRestartRequested(func() { gs.restartPending = true })
+	gs.Events.OnQuitRequested(func() { gs.ShouldClose = true })
+
+	return gs
+}
+
+// DailySeed returns the seed shared by everyone playing the daily
+// challenge on the given day.
+func DailySeed(t time.Time) int64 {
+	y, m, d := t.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// nextRunSeed returns the seed to use for the next run.
+func (s *GameScene) nextRunSeed() int64 {
+	if s.Ghost != nil {
+		return s.Ghost.Seed
+	}
+	if s.UseFixedSeed {
+		return s.FixedSeed
+	}
+	if s.GameMode == GameModeDaily {
+		return DailySeed(time.Now())
+	}
+	return rand.Int63()
+}
+
+// Score returns the score of the current run: the whole distance travelled
+// plus the bonus score from the triggers flown through.
+func (s *GameScene) Score() int64 {
+	return int64(s.distanceTravelled) + s.bonusScore
+}
+
+// DistanceTravelled returns how far the ship has flown in the current run.
+func (s *GameScene) DistanceTravelled() float64 {
+	return s.distanceTravelled
+}
+
+// State returns the state of the game, such as GameStatePlaying.
+func (s *GameScene) State() int {
+	return s.gameState
+}
+
+// recordRun adds the current run to the high score table and saves it.
+func (s *GameScene) recordRun() {
+	s.lastRunRank = -1
+	if s.HighScores == nil {
+		return
+	}
+
+	s.lastRunRank = s.HighScores.Add(HighScore{
+		Score:    s.Score(),
+		Distance: s.distanceTravelled,
+		Duration: s.currentGameTime,
+		Seed:     s.seed,
+		Date:     time.Now(),
+		Mode:     s.GameMode,
+		Name:     s.PlayerName,
+	})
+	if s.lastRunRank >= 0 {
+		err := s.HighScores.Save()
+		if err != nil {
+			fmt.Printf("Could not save the high scores: %v\n", err)
+		}
+	}
+}
+
+// recordPersonalBest saves the replay of the current run if it beat the
+// personal best for the game mode.
+func (s *GameScene) recordPersonalBest() {
+	if s.PersonalBest != nil && s.PersonalBest.Distance() >= s.distanceTravelled {
+		return
+	}
+
+	s.PersonalBest = s.runTrack
+	if s.RaceBest {
+		s.Ghost = s.runTrack
+	}
+
+	if s.PersonalBestFile == "" {
+		return
+	}
+	replayBlob, err := s.runReplay.Encode()
+	if err == nil {
+		err = atomicfile.WriteFile(s.PersonalBestFile, replayBlob)
+	}
+	if err != nil {
+		fmt.Printf("Could not save the personal best: %v\n", err)
+	}
+}
+
+// GhostDelta returns how far the player is ahead of the ghost and true, or
+// false if there's no ghost to race.
+func (s *GameScene) GhostDelta() (float64, bool) {
+	if s.ghostEntity == nil {
+		return 0.0, false
+	}
+	return s.ghostDelta, true
+}
+
+// submitRun sends the current run to the online leaderboard.
+func (s *GameScene) submitRun() {
+	if s.Leaderboard == nil {
+		return
+	}
+
+	replayBlob, err := s.runReplay.Encode()
+	if err != nil {
+		fmt.Printf("Could not submit the run to the leaderboard: %v\n", err)
+		return
+	}
+
+	s.Leaderboard.SubmitRun(&leaderboard.Submission{
+		Name:     s.PlayerName,
+		Mode:     s.GameMode,
+		Seed:     s.seed,
+		Score:    s.Score(),
+		Distance: s.distanceTravelled,
+		Duration: s.currentGameTime,
+		Date:     time.Now().UTC(),
+		Replay:   replayBlob,
+	})
+}
+
+// recordFrame adds the controls that flew the ship this frame to the replay.
+func (s *GameScene) recordFrame(frameDelta float32, controls FlightControls) {
+	s.runReplay.AddFrame(replay.Frame{
+		Delta:       frameDelta,
+		RollRate:    controls.RollRate,
+		PitchRate:   controls.PitchRate,
+		HasTarget:   controls.HasTarget,
+		TargetRoll:  controls.TargetRoll,
+		TargetPitch: controls.TargetPitch,
+		Boost:       controls.Boost,
+	})
+}
+
+// Update should be called each frame to update the scene manager.
+func (s *GameScene) Update(frameDelta float32) {
+	// store the framedelta value before running the system updates.
+	// this will allow for callback from the input system to see the
+	// current frame delta.
+	s.currentFrameDelta = frameDelta
+	if s.AssetWatcher != nil && (s.components != nil || s.headlessComponents != nil) {
+		s.checkAssetChanges(frameDelta)
+	}
+
+	s.simulatingFrame = s.gameState == GameStatePlaying
+	if s.simulatingFrame {
+		s.currentGameTime += float64(frameDelta)
+		s.sampleFrameTime(frameDelta)
+	}
+
+	// call the base version which will update the systems and then apply
+	// the changes to the entities they asked for.
+	s.BasicSceneManager.Update(frameDelta)
+	s.applyCommands()
+
+	// start a new run if one was asked for during the update. the rest of
+	// the frame belonged to the old run so nothing more is simulated.
+	if s.restartPending {
+		s.restartPending = false
+		s.restartRun()
+		return
+	}
+
+	// if the player is dead or the game is paused we don't do anything on update
+	if !s.simulatingFrame {
+		return
+	}
+
+	s.simulateFrame(frameDelta)
+}
+
+// simulateFrame runs the game logic for a frame after the ship has been
+// flown: spawning, collisions and scrolling everything past the ship. It
+// does not depend on any systems so that runs can be re-simulated headless.
+func (s *GameScene) simulateFrame(frameDelta float32) {
+	// ======================================================================
+	// test to see if we need to spawn walls
+	s.SpawnNewWalls()
+
+	// ======================================================================
+	// test to see if we need to spawn some bombs
+	s.SpawnNewBombs()
+
+	// ======================================================================
+	// check the colliders against each other to see if we have a hit.
+	s.crash = nil
+	s.checkCollisions()
+	s.updateTriggers()
+	s.collectPickups()
+
+	// if the player hits another entity it's considered the end of the road!
+	if s.crash != nil && s.gameState != GameStatePlayerDied {
+		s.setGameState(GameStatePlayerDied)
+		s.deathCause = s.crash.B.GetName()
+		hit := newEntityEvent(telemetry.TypeHit, s.crash.B, s.shipEntity.GetLocation())
+		hit.Contact = &telemetry.Vec3{s.crash.Point[0], s.crash.Point[1], s.crash.Point[2]}
+		s.logEvent(hit)
+		s.logEntityEvent(telemetry.TypeDeath, s.crash.B, s.shipEntity.GetLocation())
+		s.onPlayerDied(s.crash.B)
+	}
+
+	// calculate the distance the ship has travelled so far
+	dist := float64(s.shipEntity.currentShipSpeed.Mul(s.currentFrameDelta)[2])
+	s.distSinceLastGridSpawn += dist
+	s.distanceTravelled += dist
+
+	// record where the ship is for ghosts of this run and move the ghost
+	// of the run being raced.
+	s.runTrack.AddSample(GhostSample{
+		Time:        s.currentGameTime,
+		Distance:    s.distanceTravelled,
+		Location:    s.shipEntity.GetLocation(),
+		Orientation: s.shipEntity.GetOrientation(),
+	})
+	if s.ghostEntity != nil {
+		s.ghostDelta = s.ghostEntity.Follow(s.currentGameTime, s.distanceTravelled)
+	}
+	s.logSpeedChange()
+	if s.Achievements != nil {
+		s.Achievements.Update(frameDelta, dist, s.shipEntity.lateralVelocity.Len())
+	}
+
+	// ======================================================================
+	// go through all entities and update positions of everything
+	// that's not the player
+	backwardSpeed := s.shipEntity.currentShipSpeed.Mul(-s.currentFrameDelta)
+	s.BasicSceneManager.MapEntities(func(id uint64, e scene.Entity) {
+		// skip the ship and the player entities
+		if id == s.shipEntity.ID || id == s.playerEntity.ID {
+			return
+		}
+
+		// see if it implements the ScrollableEntity interface. entities
+		// attached to another are moved along with it instead.
+		scrollableEntity, scrollable := e.(ScrollableEntity)
+		if se, okay := e.(SpawnableEntity); okay && se.GetVisibleEntity().GetParent() != nil {
+			scrollable = false
+		}
+		if scrollable {
+			prevZ := e.GetLocation()[2]
+			scrollableEntity.ScrollPastPlayer(backwardSpeed, frameDelta, s.currentGameTime)
+			if bomb, okay := e.(*BombEntity); okay {
+				if miss, near := s.checkNearMiss(bomb, prevZ); near {
+					s.logNearMiss(bomb, miss)
+					if s.Achievements != nil {
+						s.Achievements.NearMiss()
+					}
+				}
+			}
+
+			// if it's far away, despawn it
+			if e.GetLocation()[2] < -100.0 {
+				s.Commands.Despawn(e)
+			}
+		}
+
+	})
+	s.applyCommands()
+}
+
+// onPlayerDied records the run that just ended and lets everything else
+// know the ship crashed into cause, such as to show the quit menu.
+func (s *GameScene) onPlayerDied(cause scene.Entity) {
+	s.recordRun()
+	s.recordPersonalBest()
+	s.submitRun()
+	s.CloseTelemetry()
+	if s.Achievements != nil {
+		s.Achievements.RunEnded(s.GameMode)
+	}
+
+	s.Events.PublishPlayerDied(&PlayerDiedEvent{
+		Cause:    cause,
+		Score:    s.Score(),
+		Distance: s.distanceTravelled,
+		Rank:     s.lastRunRank,
+	})
+}
+
+// setGameState changes the game state and publishes the change.
+func (s *GameScene) setGameState(state int) {
+	if s.gameState == state {
+		return
+	}
+	from := s.gameState
+	s.gameState = state
+	s.Events.PublishStateChanged(&StateChangedEvent{From: from, To: state})
+}
+
+// ShowToast shows a notification that doesn't interrupt the game in
+// whichever user interface is subscribed to them.
+func (s *GameScene) ShowToast(title string, text string) {
+	s.Events.PublishToast(&ToastEvent{Title: title, Text: text})
+}
+
+// ShowMainMenu puts the game in the main menu state if there's a user
+// interface to show it. Without one the game starts playing immediately.
+func (s *GameScene) ShowMainMenu() {
+	if s.Events.PublishMainMenu() {
+		s.setGameState(GameStateMainMenu)
+	}
+}
+
+// TogglePause will pause the game if it's being played or resume it if it's paused.
+// It has no effect once the player has died.
+func (s *GameScene) TogglePause() {
+	switch s.gameState {
+	case GameStatePlaying:
+		s.setGameState(GameStatePaused)
+	case GameStatePaused:
+		s.setGameState(GameStatePlaying)
+	}
+}
+
+// restartRun starts a new run, quitting the game if it can't be set up. It's
+// called from Update for the restarts requested on the event bus.
+func (s *GameScene) restartRun() {
+	err := s.ResetScene()
+	if err != nil {
+		fmt.Printf("Could not reset the game: %v\n", err)
+		s.ShouldClose = true
+	}
+}
+
+// AddSystem adds the system to the scene and subscribes it to the events
+// of the scene if it's an EventSubscriber. A RenderSystem becomes the one
+// the scene sets up its lights in.
+func (s *GameScene) AddSystem(system scene.System) {
+	s.BasicSceneManager.AddSystem(system)
+	if renderSystem, okay := system.(RenderSystem); okay {
+		s.renderSystem = renderSystem
+	}
+	if subscriber, okay := system.(EventSubscriber); okay {
+		subscriber.SubscribeEvents(&s.Events)
+	}
+}
+
+// AddEntity adds the entity to the scene and publishes its spawning.
+func (s *GameScene) AddEntity(e scene.Entity) {
+	s.BasicSceneManager.AddEntity(e)
+	s.Events.PublishEntitySpawned(&EntityEvent{Entity: e})
+}
+
+// RemoveEntity removes the entity from the scene and publishes its despawning.
+func (s *GameScene) RemoveEntity(e scene.Entity) {
+	s.BasicSceneManager.RemoveEntity(e)
+	s.Events.PublishEntityDespawned(&EntityEvent{Entity: e})
+}
+
+// ResetScene removes all entities and regenerates the initial scene
+func (s *GameScene) ResetScene() error {
+	// remove all existing entities, dropping any changes requested for them
+	s.Commands.discard()
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		s.Commands.Despawn(e)
+	})
+	s.applyCommands()
+
+	s.currentGameTime = 0.0
+	s.distSinceLastGridSpawn = 0.0
+	s.lastBombSpawn = 0.0
+	s.distanceTravelled = 0.0
+	s.deathCause = ""
+	s.bonusScore = 0
+	s.checkpointsPassed = 0
+	s.triggersInside = make(map[triggerKey]scene.Entity)
+	s.triggersTouched = make(map[triggerKey]scene.Entity)
+	s.pickupsTouched = make(map[uint64]*CollisionEvent)
+
+	s.spawnIntervalSec = 2.0
+	s.maxToSpawn = 12
+
+	// now that things are cleaned up, setup a new scene
+	return s.SetupScene()
+}
+
+// SetupScene initializes the scene's assets and sets up the initial entities.
+// NOTE: A render System implementation will need to be added before this
+// method is called.
+func (s *GameScene) SetupScene() error {
+	// seed the random number generator for the run
+	s.seed = s.nextRunSeed()
+	s.rng = rand.New(rand.NewSource(s.seed))
+	s.runReplay = replay.New(s.seed, s.GameMode)
+	s.runTrack = NewGhostTrack(s.seed, s.GameMode)
+	s.startTelemetry()
+
+	// headless scenes only need the collision data of the components
+	if s.headless {
+		err := s.loadHeadlessComponents()
+		if err != nil {
+			return err
+		}
+		return s.createInitialEntities()
+	}
+
+	// the render system has to be added first for the lights
+	if s.renderSystem == nil {
+		return fmt.Errorf("Need to add a render System implementation first")
+	}
+
+	// load the shaders necessary
+	if len(s.shaders) < 1 {
+		err := s.createShaders()
+		if err != nil {
+			return err
+		}
+	}
+
+	// setup the texture manager for use in the component manager
+	if s.textureMan == nil {
+		s.textureMan = fizzle.NewTextureManager()
+	}
+
+	// create the component manager
+	if s.components == nil {
+		componentFiles, err := resolveGameComponents()
+		if err != nil {
+			return err
+		}
+
+		collisionMeshes, err := loadCollisionMeshes(componentFiles)
+		if err != nil {
+			return err
+		}
+
+		s.components = component.NewManager(s.textureMan, s.shaders)
+		for name, filename := range componentFiles {
+			_, err := s.loadComponent(s.components, filename, name)
+			if err != nil {
+				s.components = nil
+				return fmt.Errorf("failed to load the %s component: %v", name, err)
+			}
+		}
+		s.componentFiles = componentFiles
+		s.collisionMeshes = collisionMeshes
+	}
+
+	// put a light in there
+	renderer := s.renderSystem.GetRenderer()
+	if renderer.ActiveLights[0] == nil {
+		light := renderer.NewDirectionalLight(mgl.Vec3{1.0, -0.5, -1.0})
+		light.DiffuseIntensity = 0.20
+		light.SpecularIntensity = 0.10
+		light.AmbientIntensity = 1.0
+		renderer.ActiveLights[0] = light
+	}
+
+	return s.createInitialEntities()
+}
+
+// loadHeadlessComponents parses the component files without loading any of
+// the meshes or textures so that a scene can be simulated without graphics.
+func (s *GameScene) loadHeadlessComponents() error {
+	if s.headlessComponents != nil {
+		return nil
+	}
+
+	componentFiles, err := resolveGameComponents()
+	if err != nil {
+		return err
+	}
+
+	components := make(map[string]*component.Component)
+	for name, filename := range componentFiles {
+		jsonBytes, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to load the %s component: %v", name, err)
+		}
+		c := new(component.Component)
+		err = json.Unmarshal(jsonBytes, c)
+		if err != nil {
+			return fmt.Errorf("failed to load the %s component: %v", name, err)
+		}
+		components[name] = c
+	}
+
+	collisionMeshes, err := loadCollisionMeshes(componentFiles)
+	if err != nil {
+		return err
+	}
+	s.headlessComponents = components
+	s.componentFiles = componentFiles
+	s.collisionMeshes = collisionMeshes
+	return nil
+}
+
+// getComponent returns the component loaded with the name specified.
+func (s *GameScene) getComponent(name string) *component.Component {
+	if s.headless {
+		return s.headlessComponents[name]
+	}
+	c, _ := s.components.GetComponent(name)
+	return c
+}
+
+// getRenderableInstance returns a new renderable for the component or nil
+// for headless scenes.
+func (s *GameScene) getRenderableInstance(c *component.Component) *fizzle.Renderable {
+	if s.headless {
+		return nil
+	}
+	return s.components.GetRenderableInstance(c)
+}
+
+// createInitialEntities creates the walls, ship and player for a new run.
+func (s *GameScene) createInitialEntities() error {
+	// create the grid
+	for z := float32(12.5); z <= 212.5; z += 25.0 {
+		_, err := s.Spawn(entityKindWall, Transform{Location: mgl.Vec3{0, 0, z}},
+			SpawnOptions{Name: fmt.Sprintf("GridProto_pre%d", int(z))})
+		if err != nil {
+			return err
+		}
+	}
+
+	// add the ship in
+	ship, err := s.Spawn(entityKindShip, Transform{Location: mgl.Vec3{0.0, playerSpawnY, 0.0}},
+		SpawnOptions{Name: PlayerShipEntityName})
+	if err != nil {
+		return err
+	}
+	s.shipEntity = ship.(*ShipEntity)
+
+	// create the player entity, riding along with the ship without rolling with it
+	// FIXME: Is this really a visible entity??
+	s.playerEntity = NewVisibleEntity()
+	s.playerEntity.ID = s.GetNextID()
+	s.playerEntity.Name = PlayerEntityName
+	s.playerEntity.IgnoreParentOrientation = true
+	err = s.shipEntity.Attach(s.playerEntity, Transform{Location: playerRigOffset})
+	if err != nil {
+		return err
+	}
+	s.AddEntity(s.playerEntity)
+
+	// add the ghost of the run being raced
+	s.ghostEntity = nil
+	s.ghostDelta = 0.0
+	if s.Ghost != nil && !s.headless {
+		ghost, err := s.Spawn(entityKindGhost, Transform{}, SpawnOptions{
+			Name:  ghostEntityName,
+			Setup: func(e SpawnableEntity) { e.(*GhostEntity).Follow(0.0, 0.0) },
+		})
+		if err != nil {
+			return err
+		}
+		s.ghostEntity = ghost.(*GhostEntity)
+	}
+
+	if s.Achievements != nil {
+		s.Achievements.StartRun()
+	}
+
+	// set the state to playing
+	s.setGameState(GameStatePlaying)
+	s.Events.PublishRunStarted(&RunStartedEvent{
+		Seed:     s.seed,
+		Mode:     s.GameMode,
+		HasGhost: s.ghostEntity != nil,
+	})
+
+	return nil
+}
+
+// createShaders will load the shaders necessary for the game scene.
+func (s *GameScene) createShaders() error {
+	// load the diffuse shader for the cube
+	var err error
+	basicShader, err := forward.CreateBasicShader()
+	if err != nil {
+		return fmt.Errorf("Failed to compile and link the diffuse shader program!\n%v", err)
+	}
+	s.shaders["Basic"] = basicShader
+
+	return nil
+}
+
+// SpawnNewWalls will spawn new walls for the player to fly around if
+// the time is right.
+func (s *GameScene) SpawnNewWalls() {
+	const gridSegmentLength = 25.0
+	const spawnDistance = 200.0 + (gridSegmentLength / 2.0)
+
+	overshot := float32(s.distSinceLastGridSpawn - gridSegmentLength)
+	if overshot > 0.0 {
+		_, err := s.Spawn(entityKindWall, Transform{Location: mgl.Vec3{0, 0, spawnDistance - overshot}},
+			SpawnOptions{Name: fmt.Sprintf("GridProto_%d", int(s.distanceTravelled))})
+		if err != nil {
+			fmt.Printf("Failed to spawn the walls: %v\n", err)
+		}
+
+		// we created the wall at the spawn distance, adjusted for any travels past the
+		// grid segment length.
+		// so now we keep the remaining overshot so everything will line up again.
+		s.distSinceLastGridSpawn = float64(overshot)
+
+		//fmt.Printf("Created grid proto: %s\n", gridProtoEntity.Name)
+	}
+}
+
+// SpawnNewBombs will spawn new bombs for the player to avoid.
+func (s *GameScene) SpawnNewBombs() {
+	const spawnDistance = 250.0
+	const minToSpawn = 1
+	const minX = -10
+	const maxX = 10
+	const minY = 1
+	const maxY = 14
+	const minZDelta = -10
+	const maxZDelta = 10
+
+	// return now if we haven't hit the spawn timer
+	if s.currentGameTime-s.lastBombSpawn <= s.spawnIntervalSec {
+		return
+	}
+
+	spawnCount := s.rng.Intn(s.maxToSpawn-minToSpawn) + minToSpawn
+
+	s.logEvent(&telemetry.Event{
+		Type:  telemetry.TypeWave,
+		Count: spawnCount,
+	})
+
+	// spawn new bombs. the location is picked in Setup, after the bomb has
+	// drawn its movement curve from the rng, to keep the spawns of a seed
+	// the same as they've always been.
+	placeBomb := func(e SpawnableEntity) {
+		x := s.rng.Intn(maxX-minX) + minX
+		y := s.rng.Intn(maxY-minY) + minY
+		z := s.rng.Intn(maxZDelta-minZDelta) + minZDelta
+		e.GetVisibleEntity().SetLocation(mgl.Vec3{float32(x), float32(y), float32(spawnDistance + z)})
+	}
+	for i := 0; i < spawnCount; i++ {
+		_, err := s.Spawn(entityKindBomb, Transform{}, SpawnOptions{
+			Name:  fmt.Sprintf("Bomb_%d_%d", i, int(s.distanceTravelled)),
+			Setup: placeBomb,
+		})
+		if err != nil {
+			fmt.Printf("Failed to spawn a bomb: %v\n", err)
+			break
+		}
+	}
+	// reset the timer
+	s.lastBombSpawn = s.currentGameTime
+}
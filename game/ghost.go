@@ -0,0 +1,181 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle"
+	"github.com/tbogdala/infinigrid/replay"
+)
+
+const (
+	ghostEntityName = "Ghost"
+
+	// ghostAlpha is the opacity the ghost ship is drawn with.
+	ghostAlpha = 0.35
+
+	// GhostFileFormat is the name of the file in the user data directory
+	// that stores the replay of the personal best for a game mode.
+	GhostFileFormat = "ghost_%s.igr"
+)
+
+// GhostSample is the state of the ship at a point in time during a run.
+type GhostSample struct {
+	// Time is the game time of the sample in seconds.
+	Time float64
+
+	// Distance is how far the ship had travelled down the tunnel.
+	Distance float64
+
+	Location    mgl.Vec3
+	Orientation mgl.Quat
+}
+
+// GhostTrack is the trajectory of the ship over a run sampled every frame.
+type GhostTrack struct {
+	Seed    int64
+	Mode    string
+	Samples []GhostSample
+}
+
+// NewGhostTrack returns a new empty track for a run with the seed and mode.
+func NewGhostTrack(seed int64, mode string) *GhostTrack {
+	t := new(GhostTrack)
+	t.Seed = seed
+	t.Mode = mode
+	return t
+}
+
+// NewGhostTrackFromReplay re-simulates the replay headless to record the
+// trajectory of the ship.
+func NewGhostTrackFromReplay(rep *replay.Replay) (*GhostTrack, error) {
+	s, err := newReplayScene(rep, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range rep.Frames {
+		s.simulateReplayFrame(f)
+		if s.gameState == GameStatePlayerDied {
+			break
+		}
+	}
+	return s.runTrack, nil
+}
+
+// LoadGhostTrack loads the replay file and records the trajectory of the ship from it.
+func LoadGhostTrack(filename string) (*GhostTrack, error) {
+	rep, err := replay.LoadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewGhostTrackFromReplay(rep)
+}
+
+// AddSample appends a sample to the track.
+func (t *GhostTrack) AddSample(sample GhostSample) {
+	t.Samples = append(t.Samples, sample)
+}
+
+// Distance returns the total distance travelled during the run.
+func (t *GhostTrack) Distance() float64 {
+	if len(t.Samples) == 0 {
+		return 0.0
+	}
+	return t.Samples[len(t.Samples)-1].Distance
+}
+
+// SampleAt returns the state of the ship at the game time, interpolating
+// between the recorded samples. Times past the end of the track return the
+// last sample.
+func (t *GhostTrack) SampleAt(gameTime float64) GhostSample {
+	if len(t.Samples) == 0 {
+		return GhostSample{Orientation: mgl.QuatIdent()}
+	}
+
+	i := sort.Search(len(t.Samples), func(i int) bool {
+		return t.Samples[i].Time >= gameTime
+	})
+	if i == 0 {
+		return t.Samples[0]
+	} else if i == len(t.Samples) {
+		return t.Samples[len(t.Samples)-1]
+	}
+
+	a := t.Samples[i-1]
+	b := t.Samples[i]
+	amount := float32((gameTime - a.Time) / (b.Time - a.Time))
+	return GhostSample{
+		Time:        gameTime,
+		Distance:    a.Distance + (b.Distance-a.Distance)*float64(amount),
+		Location:    a.Location.Add(b.Location.Sub(a.Location).Mul(amount)),
+		Orientation: mgl.QuatSlerp(a.Orientation, b.Orientation, amount),
+	}
+}
+
+// GhostEntity is a translucent ship that follows a recorded track. It has no
+// colliders and doesn't scroll with the rest of the level.
+type GhostEntity struct {
+	*VisibleEntity
+
+	Track *GhostTrack
+}
+
+// NewGhostEntity returns a new ghost entity following the track.
+func NewGhostEntity(track *GhostTrack) *GhostEntity {
+	g := new(GhostEntity)
+	g.VisibleEntity = NewVisibleEntity()
+	g.Track = track
+	return g
+}
+
+// IsTranslucent returns true so that the ghost is drawn after the opaque entities.
+func (g *GhostEntity) IsTranslucent() bool {
+	return true
+}
+
+// SetRenderable sets the renderable for the ghost, giving it copies of the
+// materials so that the opacity can be changed without affecting the other
+// instances of the component.
+func (g *GhostEntity) SetRenderable(r *fizzle.Renderable) {
+	g.Renderable = r
+	if r == nil {
+		return
+	}
+
+	var makeTranslucent func(r *fizzle.Renderable)
+	makeTranslucent = func(r *fizzle.Renderable) {
+		if r.Material != nil {
+			material := *r.Material
+			material.DiffuseColor[3] = ghostAlpha
+			r.Material = &material
+		}
+		for _, child := range r.Children {
+			makeTranslucent(child)
+		}
+	}
+	makeTranslucent(r)
+}
+
+// Follow moves the ghost to where it was at the game time relative to the
+// player's ship which has travelled playerDistance. It returns how far the
+// player is ahead of the ghost.
+func (g *GhostEntity) Follow(gameTime float64, playerDistance float64) float64 {
+	sample := g.Track.SampleAt(gameTime)
+	delta := playerDistance - sample.Distance
+	g.SetLocation(sample.Location.Add(mgl.Vec3{0.0, 0.0, float32(-delta)}))
+	g.SetOrientation(sample.Orientation)
+	return delta
+}
+
+// FormatGhostDelta returns the distance delta to the ghost for display.
+func FormatGhostDelta(delta float64) string {
+	if delta >= 0.0 {
+		return fmt.Sprintf("Ghost: +%.1fm ahead", delta)
+	}
+	return fmt.Sprintf("Ghost: %.1fm behind", delta)
+}
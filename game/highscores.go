@@ -0,0 +1,119 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tbogdala/infinigrid/atomicfile"
+)
+
+const (
+	HighScoreFileName   = "highscores.json"
+	HighScoreMaxEntries = 10
+)
+
+// HighScore is a single completed run stored in the high score table.
+type HighScore struct {
+	Score    int64
+	Distance float64
+	Duration float64 // seconds
+	Seed     int64
+	Date     time.Time
+	Mode     string
+	Name     string
+}
+
+// HighScoreTable is the local table of the best runs sorted by score.
+type HighScoreTable struct {
+	// MaxEntries is the number of runs kept in the table.
+	MaxEntries int
+
+	// Entries are the runs in the table sorted from best to worst.
+	Entries []HighScore
+
+	filename string
+}
+
+// LoadHighScoreTable loads the high score table from the file specified. If
+// the file does not exist yet, an empty table is returned.
+func LoadHighScoreTable(filename string, maxEntries int) (*HighScoreTable, error) {
+	table := new(HighScoreTable)
+	table.MaxEntries = maxEntries
+	table.filename = filename
+
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return table, nil
+	} else if err != nil {
+		return table, fmt.Errorf("failed to read the high score table: %v", err)
+	}
+
+	err = json.Unmarshal(jsonBytes, &table.Entries)
+	if err != nil {
+		return table, fmt.Errorf("failed to parse the high score table: %v", err)
+	}
+	table.sortAndTrim()
+
+	return table, nil
+}
+
+// sortAndTrim sorts the entries by score and drops any beyond MaxEntries.
+func (t *HighScoreTable) sortAndTrim() {
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		return t.Entries[i].Score > t.Entries[j].Score
+	})
+	if len(t.Entries) > t.MaxEntries {
+		t.Entries = t.Entries[:t.MaxEntries]
+	}
+}
+
+// Add inserts the run into the table and returns its zero-based rank, or -1
+// if the run didn't score high enough to be kept.
+func (t *HighScoreTable) Add(hs HighScore) int {
+	rank := len(t.Entries)
+	for i, e := range t.Entries {
+		if hs.Score > e.Score {
+			rank = i
+			break
+		}
+	}
+	if rank >= t.MaxEntries {
+		return -1
+	}
+
+	t.Entries = append(t.Entries, HighScore{})
+	copy(t.Entries[rank+1:], t.Entries[rank:])
+	t.Entries[rank] = hs
+	t.sortAndTrim()
+
+	return rank
+}
+
+// Best returns the best run in the table and true, or false if the table is empty.
+func (t *HighScoreTable) Best() (HighScore, bool) {
+	if len(t.Entries) == 0 {
+		return HighScore{}, false
+	}
+	return t.Entries[0], true
+}
+
+// Save writes the table back out to the file it was loaded from.
+func (t *HighScoreTable) Save() error {
+	jsonBytes, err := json.MarshalIndent(t.Entries, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize the high score table: %v", err)
+	}
+
+	err = atomicfile.WriteFile(t.filename, jsonBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write the high score table: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	fizzle "github.com/tbogdala/fizzle"
+	component "github.com/tbogdala/fizzle/component"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+const (
+	// assetPollInterval is how often, in seconds, the asset roots are
+	// checked for changes in dev mode.
+	assetPollInterval = 1.0
+)
+
+// AssetWatcher polls the asset roots for files that have been added,
+// changed or removed.
+type AssetWatcher struct {
+	Roots []string
+
+	// files holds the modification time of every file under the roots.
+	files    map[string]time.Time
+	timeLeft float64
+}
+
+// NewAssetWatcher creates a new watcher for the asset roots and takes note
+// of the files currently in them.
+func NewAssetWatcher(roots []string) *AssetWatcher {
+	w := new(AssetWatcher)
+	w.Roots = roots
+	w.files = w.scan()
+	w.timeLeft = assetPollInterval
+	return w
+}
+
+// scan returns the modification times of all the files under the roots.
+func (w *AssetWatcher) scan() map[string]time.Time {
+	files := make(map[string]time.Time)
+	for _, root := range w.Roots {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() {
+				files[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// Poll checks the roots for changes once the poll interval has passed and
+// returns the sorted list of files that were added, changed or removed.
+func (w *AssetWatcher) Poll(frameDelta float32) []string {
+	w.timeLeft -= float64(frameDelta)
+	if w.timeLeft > 0.0 {
+		return nil
+	}
+	w.timeLeft = assetPollInterval
+
+	files := w.scan()
+	var changed []string
+	for path, modTime := range files {
+		if old, okay := w.files[path]; !okay || !old.Equal(modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.files {
+		if _, okay := files[path]; !okay {
+			changed = append(changed, path)
+		}
+	}
+	w.files = files
+
+	sort.Strings(changed)
+	return changed
+}
+
+// checkAssetChanges polls the asset roots in dev mode and reloads the
+// components if any of their files changed.
+func (s *GameScene) checkAssetChanges(frameDelta float32) {
+	changed := s.AssetWatcher.Poll(frameDelta)
+	if len(changed) == 0 {
+		return
+	}
+
+	fmt.Printf("Assets changed: %s\n", strings.Join(changed, ", "))
+	err := s.reloadComponents(changed)
+	if err != nil {
+		fmt.Printf("Failed to reload the assets.\n%v\n", err)
+	}
+	s.showAssetErrors(err)
+}
+
+// reloadComponents loads the components again with a new component manager
+// after the files were changed. If they all load, the new manager replaces
+// the current one and the live entities using the changed components get new
+// renderables, colliders and ship handling. Otherwise the current components
+// are kept. Headless scenes only reload the collision data.
+func (s *GameScene) reloadComponents(changedFiles []string) error {
+	componentFiles, err := resolveGameComponents()
+	if err != nil {
+		return err
+	}
+
+	// parse the component files first to report all of the broken ones at
+	// once. the ship handling is checked here too so that a bad value is
+	// reported rather than leaving the ships with the old handling.
+	parsed := make(map[string]*component.Component)
+	var parseErrors []string
+	for _, name := range sortedKeys(componentFiles) {
+		filename := componentFiles[name]
+		c := new(component.Component)
+		jsonBytes, err := ioutil.ReadFile(filename)
+		if err == nil {
+			err = json.Unmarshal(jsonBytes, c)
+		}
+		if err == nil {
+			handling := NewShipHandling()
+			err = handling.LoadFromComponent(c)
+		}
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("%s: %v", filename, err))
+			continue
+		}
+		parsed[name] = c
+	}
+	if len(parseErrors) > 0 {
+		return fmt.Errorf("%s", strings.Join(parseErrors, "\n"))
+	}
+
+	collisionMeshes, err := loadCollisionMeshes(componentFiles)
+	if err != nil {
+		return err
+	}
+
+	var components *component.Manager
+	var staleTextures []graphics.Texture
+	if !s.headless {
+		// the texture manager caches textures by name, so changed texture
+		// files are loaded again before the components pick them up.
+		staleTextures, err = s.reloadChangedTextures(componentFiles, parsed, changedFiles)
+		if err != nil {
+			return err
+		}
+
+		components = component.NewManager(s.textureMan, s.shaders)
+		for name, filename := range componentFiles {
+			_, err := s.loadComponent(components, filename, name)
+			if err != nil {
+				return fmt.Errorf("failed to load the %s component: %v", name, err)
+			}
+		}
+	}
+
+	// a change to anything other than the component files themselves, such
+	// as a mesh or a texture, could affect any of the components.
+	changedComponents := make(map[string]bool)
+	isComponentFile := make(map[string]string)
+	for name, filename := range componentFiles {
+		isComponentFile[filepath.Clean(filename)] = name
+		if s.componentFiles[name] != filename {
+			changedComponents[name] = true
+		}
+	}
+	for _, filename := range changedFiles {
+		name, okay := isComponentFile[filepath.Clean(filename)]
+		if !okay {
+			for name := range componentFiles {
+				changedComponents[name] = true
+			}
+			break
+		}
+		changedComponents[name] = true
+	}
+
+	if s.headless {
+		s.headlessComponents = parsed
+	} else {
+		s.components = components
+	}
+	s.componentFiles = componentFiles
+	s.collisionMeshes = collisionMeshes
+	s.rebuildRenderables(changedComponents)
+	s.rebuildColliders(changedComponents)
+	s.reloadShipHandling(changedComponents)
+
+	// nothing uses the textures that were replaced once the renderables
+	// have been rebuilt
+	if len(staleTextures) > 0 {
+		gfx := fizzle.GetGraphics()
+		for _, tex := range staleTextures {
+			gfx.DeleteTexture(tex)
+		}
+	}
+	return nil
+}
+
+// reloadChangedTextures loads the textures of the components whose files
+// changed into the texture manager again, replacing the ones it cached. It
+// returns the textures that were replaced.
+func (s *GameScene) reloadChangedTextures(componentFiles map[string]string, parsed map[string]*component.Component,
+	changedFiles []string) ([]graphics.Texture, error) {
+	changed := make(map[string]bool)
+	for _, filename := range changedFiles {
+		changed[filepath.Clean(filename)] = true
+	}
+
+	var stale []graphics.Texture
+	reloaded := make(map[string]bool)
+	for _, name := range sortedKeys(componentFiles) {
+		componentDir, _ := filepath.Split(componentFiles[name])
+		c := parsed[name]
+		applyTextureQuality(c, componentDir, s.TextureQuality)
+		for _, texture := range textureRefs(c) {
+			filename := componentDir + *texture
+			if *texture == "" || reloaded[*texture] || !changed[filepath.Clean(filename)] {
+				continue
+			}
+			old, loaded := s.textureMan.GetTexture(*texture)
+			if !loaded {
+				continue
+			}
+			_, err := s.textureMan.LoadTexture(*texture, filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the texture %s: %v", filename, err)
+			}
+			reloaded[*texture] = true
+			stale = append(stale, old)
+		}
+	}
+	return stale, nil
+}
+
+// reloadShipHandling loads the handling of the ships created from the
+// components named again so that tuning a ship takes effect in flight.
+func (s *GameScene) reloadShipHandling(componentNames map[string]bool) {
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		ship, okay := e.(*ShipEntity)
+		if !okay || !componentNames[ship.GetComponentName()] {
+			return
+		}
+		c := s.getComponent(ship.GetComponentName())
+		if c == nil {
+			return
+		}
+
+		// the handling was checked when the components were parsed
+		handling := NewShipHandling()
+		handling.LoadFromComponent(c)
+		ship.Handling = handling
+	})
+}
+
+// rebuildColliders replaces the colliders of the entities with collisions
+// that were created from the components named.
+func (s *GameScene) rebuildColliders(componentNames map[string]bool) {
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		ce, okay := e.(ComponentEntity)
+		if !okay || !componentNames[ce.GetComponentName()] {
+			return
+		}
+		if _, okay := e.(CollisionEntity); !okay {
+			return
+		}
+		rebuilder, okay := e.(ColliderRebuilder)
+		if !okay {
+			return
+		}
+		rebuilder.RebuildColliders(s.getComponent(ce.GetComponentName()))
+	})
+}
+
+// showAssetErrors shows the error from loading the assets in the user
+// interface, or hides it if err is nil.
+func (s *GameScene) showAssetErrors(err error) {
+	if err == nil {
+		s.Events.PublishAssetErrors(&AssetErrorsEvent{})
+		return
+	}
+	s.Events.PublishAssetErrors(&AssetErrorsEvent{
+		Title: "ASSET RELOAD FAILED",
+		Lines: strings.Split(err.Error(), "\n"),
+	})
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
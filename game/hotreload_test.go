@@ -0,0 +1,123 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbogdala/glider"
+)
+
+// copyTestAssets copies the test assets to a temporary directory that the
+// tests can change and returns it along with a function to remove it.
+func copyTestAssets(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "infinigrid-assets")
+	if err != nil {
+		t.Fatalf("failed to create a temporary directory: %v", err)
+	}
+	src := filepath.Join("testdata", DefaultAssetRoot)
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(rel)), 0755)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, rel), data, 0644)
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to copy the test assets: %v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// editAsset replaces old with new in the asset file and moves its
+// modification time on so the watcher sees the change.
+func editAsset(t *testing.T, filename string, old string, new string) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", filename, err)
+	}
+	if !strings.Contains(string(data), old) {
+		t.Fatalf("%s doesn't contain %q", filename, old)
+	}
+	err = ioutil.WriteFile(filename, []byte(strings.Replace(string(data), old, new, 1)), 0644)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", filename, err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filename, later, later)
+}
+
+func TestHotReloadComponents(t *testing.T) {
+	dir, cleanup := copyTestAssets(t)
+	defer cleanup()
+	AssetRoots = []string{dir}
+
+	s := NewHeadlessGameScene()
+	s.UseFixedSeed = true
+	s.FixedSeed = 1
+	err := s.SetupScene()
+	if err != nil {
+		t.Fatalf("failed to set up the scene: %v", err)
+	}
+	s.AssetWatcher = NewAssetWatcher(AssetRoots)
+	bomb, err := s.Spawn(entityKindBomb, Transform{}, SpawnOptions{})
+	if err != nil {
+		t.Fatalf("failed to spawn a bomb: %v", err)
+	}
+	s.applyCommands()
+
+	var reported []*AssetErrorsEvent
+	s.Events.OnAssetErrors(func(e *AssetErrorsEvent) { reported = append(reported, e) })
+
+	// a broken file is reported and the components in use are kept
+	bombFile := filepath.Join(dir, "components", "bomb.json")
+	editAsset(t, bombFile, `"Radius": 0.7,`, `"Radius": 0.7,,`)
+	s.checkAssetChanges(assetPollInterval)
+	if len(reported) != 1 || len(reported[0].Lines) == 0 || !strings.Contains(reported[0].Lines[0], "bomb.json") {
+		t.Fatalf("expected the broken bomb to be reported, got %+v", reported)
+	}
+	radius := bomb.GetVisibleEntity().CoarseColliders[0].(*glider.Sphere).Radius
+	if radius != 0.7 {
+		t.Errorf("the bomb's collider changed to a radius of %f after a failed reload", radius)
+	}
+
+	// fixing it reloads the collider of the live bomb and the ship's handling
+	editAsset(t, bombFile, `"Radius": 0.7,,`, `"Radius": 1.25,`)
+	editAsset(t, filepath.Join(dir, "components", "ship.json"), `"Handling.Thrust": "60.0"`, `"Handling.Thrust": "90.0"`)
+	s.checkAssetChanges(assetPollInterval)
+	if len(reported) != 2 || len(reported[1].Lines) != 0 {
+		t.Fatalf("expected the errors to be cleared, got %+v", reported[len(reported)-1])
+	}
+	radius = bomb.GetVisibleEntity().CoarseColliders[0].(*glider.Sphere).Radius
+	if radius != 1.25 {
+		t.Errorf("expected the live bomb to have a radius of 1.25, got %f", radius)
+	}
+	if s.shipEntity.Handling.Thrust != 90.0 {
+		t.Errorf("expected the ship's thrust to be reloaded as 90, got %f", s.shipEntity.Handling.Thrust)
+	}
+
+	// a bad handling value is reported rather than ignored
+	editAsset(t, filepath.Join(dir, "components", "ship.json"), `"Handling.Thrust": "90.0"`, `"Handling.Thrust": "fast"`)
+	s.checkAssetChanges(assetPollInterval)
+	if len(reported) != 3 || len(reported[2].Lines) == 0 {
+		t.Errorf("expected the bad handling to be reported, got %+v", reported[len(reported)-1])
+	}
+	if s.shipEntity.Handling.Thrust != 90.0 {
+		t.Errorf("the ship's thrust changed to %f after a failed reload", s.shipEntity.Handling.Thrust)
+	}
+}
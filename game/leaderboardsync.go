@@ -0,0 +1,96 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tbogdala/infinigrid/leaderboard"
+)
+
+const (
+	LeaderboardQueueFileName = "leaderboard-queue.json"
+	leaderboardTopLimit      = 10
+)
+
+// LeaderboardSync talks to the online leaderboard in the background so
+// that the game never waits on the network.
+type LeaderboardSync struct {
+	client *leaderboard.Client
+	mode   string
+
+	lock   sync.Mutex
+	top    []leaderboard.Entry
+	status string
+}
+
+// NewLeaderboardSync creates a new LeaderboardSync object for the game mode
+// and starts fetching the top list.
+func NewLeaderboardSync(client *leaderboard.Client, mode string) *LeaderboardSync {
+	ls := new(LeaderboardSync)
+	ls.client = client
+	ls.mode = mode
+	ls.status = "Connecting..."
+	ls.Refresh()
+	return ls
+}
+
+// GetTop returns the last fetched top list and a status message describing
+// the connection to the leaderboard.
+func (ls *LeaderboardSync) GetTop() ([]leaderboard.Entry, string) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	return ls.top, ls.status
+}
+
+func (ls *LeaderboardSync) setStatus(status string) {
+	ls.lock.Lock()
+	ls.status = status
+	ls.lock.Unlock()
+}
+
+// Refresh retries any queued submissions and fetches the top list in the background.
+func (ls *LeaderboardSync) Refresh() {
+	go func() {
+		ls.flushAndFetch()
+	}()
+}
+
+// SubmitRun sends the run to the leaderboard in the background and then
+// refreshes the top list.
+func (ls *LeaderboardSync) SubmitRun(sub *leaderboard.Submission) {
+	go func() {
+		_, err := ls.client.Submit(sub)
+		if leaderboard.IsQueued(err) {
+			if serr, okay := err.(*leaderboard.QueueSaveError); okay {
+				fmt.Printf("The run is waiting to be sent but will be lost if the game exits first: %v\n", serr.Err)
+			}
+			ls.setStatus(fmt.Sprintf("Offline; %d runs waiting to be sent.", ls.client.Pending()))
+			return
+		} else if err != nil {
+			fmt.Printf("The leaderboard rejected the run: %v\n", err)
+		}
+		ls.flushAndFetch()
+	}()
+}
+
+func (ls *LeaderboardSync) flushAndFetch() {
+	if ls.client.Pending() > 0 {
+		_, err := ls.client.Flush()
+		if err != nil {
+			fmt.Printf("Failed to send the queued runs to the leaderboard: %v\n", err)
+		}
+	}
+
+	top, err := ls.client.Top(ls.mode, leaderboardTopLimit)
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	if err != nil {
+		ls.status = fmt.Sprintf("Offline; %d runs waiting to be sent.", ls.client.Pending())
+		return
+	}
+	ls.top = top
+	ls.status = ""
+}
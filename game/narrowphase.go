@@ -0,0 +1,137 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	component "github.com/tbogdala/fizzle/component"
+	scene "github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/trimesh"
+)
+
+const (
+	// narrowPhaseProperty is the component property that selects how a hit
+	// on the coarse colliders of an entity is confirmed: "coarse", the
+	// default, takes the hit as is and "mesh" only counts it if the
+	// triangles of the entity cross the triangles of the ship.
+	narrowPhaseProperty = "Collision.NarrowPhase"
+
+	// collisionMeshProperty is the component property with a simplified
+	// model, relative to the component file, to use for the narrow phase
+	// instead of the meshes of the component. It can be an OBJ or a gombz file.
+	collisionMeshProperty = "Collision.Mesh"
+)
+
+const (
+	narrowPhaseCoarse = "coarse"
+	narrowPhaseMesh   = "mesh"
+)
+
+// usesNarrowPhase returns true if the component's coarse hits should be
+// confirmed against its triangles.
+func usesNarrowPhase(c *component.Component) (bool, error) {
+	switch value := c.Properties[narrowPhaseProperty]; value {
+	case "", narrowPhaseCoarse:
+		return false, nil
+	case narrowPhaseMesh:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for %s: %q; it should be %q or %q",
+			narrowPhaseProperty, value, narrowPhaseCoarse, narrowPhaseMesh)
+	}
+}
+
+// loadCollisionMesh returns the triangles the narrow phase tests for the
+// component: the simplified collision mesh if it has one, otherwise the
+// triangles of its meshes.
+func loadCollisionMesh(filename string, c *component.Component) (*trimesh.Mesh, error) {
+	componentDir := filepath.Dir(filename)
+	if meshFile := c.Properties[collisionMeshProperty]; meshFile != "" {
+		tris, err := trimesh.LoadFile(filepath.Join(componentDir, filepath.FromSlash(meshFile)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the collision mesh %s: %v", meshFile, err)
+		}
+		return trimesh.NewMesh(tris), nil
+	}
+	tris, err := trimesh.ComponentTriangles(componentDir, c)
+	if err != nil {
+		return nil, err
+	}
+	return trimesh.NewMesh(tris), nil
+}
+
+// loadCollisionMeshes returns the narrow phase meshes of the components that
+// use it, and of the ship, which the other meshes are tested against, if any
+// of them do. The files are read directly instead of through the component
+// manager so that headless scenes get the same meshes.
+func loadCollisionMeshes(componentFiles map[string]string) (map[string]*trimesh.Mesh, error) {
+	components := make(map[string]*component.Component)
+	var names []string
+	for _, name := range sortedKeys(componentFiles) {
+		jsonBytes, err := ioutil.ReadFile(componentFiles[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the %s component: %v", name, err)
+		}
+		c := new(component.Component)
+		err = json.Unmarshal(jsonBytes, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the %s component: %v", name, err)
+		}
+		components[name] = c
+
+		narrow, err := usesNarrowPhase(c)
+		if err != nil {
+			return nil, fmt.Errorf("the %s component has %v", name, err)
+		}
+		if narrow && name != shipComponentName {
+			names = append(names, name)
+		}
+	}
+
+	meshes := make(map[string]*trimesh.Mesh)
+	if len(names) == 0 {
+		return meshes, nil
+	}
+	names = append(names, shipComponentName)
+	for _, name := range names {
+		c, okay := components[name]
+		if !okay {
+			return nil, fmt.Errorf("the %s component is needed for narrow phase collisions", name)
+		}
+		mesh, err := loadCollisionMesh(componentFiles[name], c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the narrow phase mesh of the %s component: %v", name, err)
+		}
+		meshes[name] = mesh
+	}
+	return meshes, nil
+}
+
+// confirmCollision runs the narrow phase for two entities whose coarse
+// colliders hit. It returns true if the hit stands: when either component
+// doesn't have a narrow phase mesh or when the triangles of the two cross.
+func (s *GameScene) confirmCollision(a, b scene.Entity) bool {
+	meshA := s.entityCollisionMesh(a)
+	meshB := s.entityCollisionMesh(b)
+	if meshA == nil || meshB == nil {
+		return true
+	}
+	return trimesh.Intersect(meshA, a.GetOrientation(), a.GetLocation(),
+		meshB, b.GetOrientation(), b.GetLocation())
+}
+
+// entityCollisionMesh returns the narrow phase mesh for the component of the
+// entity or nil if it doesn't have one.
+func (s *GameScene) entityCollisionMesh(e scene.Entity) *trimesh.Mesh {
+	ce, okay := e.(ComponentEntity)
+	if !okay {
+		return nil
+	}
+	return s.collisionMeshes[ce.GetComponentName()]
+}
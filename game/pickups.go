@@ -0,0 +1,47 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"sort"
+
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+// onShipPickup handles the ship touching a pickup by noting it to be
+// collected once the collisions of the frame have all been handled.
+func (s *GameScene) onShipPickup(event *CollisionEvent) {
+	if event.A.GetID() != s.shipEntity.ID {
+		return
+	}
+	if _, okay := s.pickupsTouched[event.B.GetID()]; !okay {
+		s.pickupsTouched[event.B.GetID()] = event
+	}
+}
+
+// collectPickups collects the pickups the ship touched this frame in the
+// order of their ids: the bonus in their tags is added to the score, the
+// pickup is published and then despawned at the end of the frame.
+func (s *GameScene) collectPickups() {
+	ids := make([]uint64, 0, len(s.pickupsTouched))
+	for id := range s.pickupsTouched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		event := s.pickupsTouched[id]
+		delete(s.pickupsTouched, id)
+
+		tags := colliderTags(event.B, event.ColliderB)
+		bonus := tagBonus(tags, 0)
+		s.bonusScore += bonus
+		logged := newEntityEvent(telemetry.TypePickup, event.B, event.Point)
+		logged.Tags = tags
+		logged.Bonus = bonus
+		s.logEvent(logged)
+		s.Events.PublishPickupCollected(&PickupEvent{Entity: event.B, Tags: tags, Bonus: bonus})
+		s.Commands.Despawn(event.B)
+	}
+}
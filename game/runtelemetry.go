@@ -0,0 +1,216 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	scene "github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+const (
+	// TelemetryDirName is the directory in the user data directory the run
+	// logs are written to by default.
+	TelemetryDirName = "runs"
+
+	// nearMissDistance is the furthest apart, in the X/Y plane, the centers
+	// of a bomb and the ship can be as the bomb passes for a near miss.
+	nearMissDistance = 2.5
+
+	// speedChangeThreshold is how much the forward speed of the ship has to
+	// change, in m/s, before a speed event is logged.
+	speedChangeThreshold = 1.0
+
+	// frameSampleInterval is how many seconds of play each frame event covers.
+	frameSampleInterval = 1.0
+)
+
+// runTelemetry tracks the state of the event log for the current run.
+type runTelemetry struct {
+	writer *telemetry.Writer
+
+	// output is written to instead of a file in TelemetryDir if it's set.
+	output io.Writer
+
+	lastSpeed float64
+
+	// frame time samples since the last frame event
+	frameCount    int
+	frameTotal    float64
+	frameMax      float64
+	frameInterval float64
+}
+
+// startTelemetry opens a new event log for the run if TelemetryDir or an
+// output writer is set.
+func (s *GameScene) startTelemetry() {
+	s.CloseTelemetry()
+	if s.telemetry.output != nil {
+		s.telemetry.writer = telemetry.NewWriter(s.telemetry.output)
+		s.logRunStart(time.Now())
+		return
+	}
+	if s.TelemetryDir == "" {
+		return
+	}
+
+	err := os.MkdirAll(s.TelemetryDir, 0755)
+	if err != nil {
+		fmt.Printf("Could not create the run log directory: %v\n", err)
+		return
+	}
+	now := time.Now()
+	filename := filepath.Join(s.TelemetryDir, fmt.Sprintf("run_%s_%d.jsonl", now.Format("20060102_150405"), s.seed))
+	writer, err := telemetry.Create(filename)
+	if err != nil {
+		fmt.Printf("Could not create the run log: %v\n", err)
+		return
+	}
+
+	s.telemetry.writer = writer
+	s.logRunStart(now)
+}
+
+// logRunStart writes the run_start event that begins every event log.
+func (s *GameScene) logRunStart(now time.Time) {
+	s.logEvent(&telemetry.Event{
+		Type: telemetry.TypeRunStart,
+		Run: &telemetry.RunInfo{
+			Version: telemetry.SchemaVersion,
+			Seed:    s.seed,
+			Mode:    s.GameMode,
+			Player:  s.PlayerName,
+			Date:    now.UTC(),
+		},
+	})
+}
+
+// CloseTelemetry closes the event log for the run if one is open.
+func (s *GameScene) CloseTelemetry() {
+	if s.telemetry.writer != nil {
+		err := s.telemetry.writer.Close()
+		if err != nil {
+			fmt.Printf("Could not write the run log: %v\n", err)
+		}
+	}
+	s.telemetry = runTelemetry{output: s.telemetry.output}
+}
+
+// logEvent timestamps the event and writes it to the event log for the run.
+func (s *GameScene) logEvent(e *telemetry.Event) {
+	if s.telemetry.writer == nil {
+		return
+	}
+
+	e.Time = s.currentGameTime
+	e.Distance = s.distanceTravelled
+	err := s.telemetry.writer.Write(e)
+	if err != nil {
+		fmt.Printf("Could not write the run log: %v\n", err)
+		s.CloseTelemetry()
+	}
+}
+
+// logEntityEvent writes an event about the entity at the position to the event log.
+func (s *GameScene) logEntityEvent(eventType string, e scene.Entity, pos mgl.Vec3) {
+	if s.telemetry.writer == nil {
+		return
+	}
+	s.logEvent(newEntityEvent(eventType, e, pos))
+}
+
+// newEntityEvent returns an event about the entity at the position for the
+// events that log more than the entity.
+func newEntityEvent(eventType string, e scene.Entity, pos mgl.Vec3) *telemetry.Event {
+	event := &telemetry.Event{
+		Type:     eventType,
+		Entity:   e.GetName(),
+		Position: &telemetry.Vec3{pos[0], pos[1], pos[2]},
+	}
+	if ce, okay := e.(ComponentEntity); okay {
+		event.Component = ce.GetComponentName()
+	}
+	return event
+}
+
+// checkNearMiss returns the distance between the centers of the bomb and the
+// ship in the X/Y plane and true if the bomb just passed the ship close enough
+// for a near miss. prevZ is the location of the bomb on the Z axis before it
+// was scrolled this frame.
+func (s *GameScene) checkNearMiss(bomb *BombEntity, prevZ float32) (float32, bool) {
+	shipLoc := s.shipEntity.GetLocation()
+	bombLoc := bomb.GetLocation()
+	if prevZ < shipLoc[2] || bombLoc[2] >= shipLoc[2] {
+		return 0.0, false
+	}
+
+	miss := mgl.Vec2{bombLoc[0] - shipLoc[0], bombLoc[1] - shipLoc[1]}.Len()
+	return miss, miss <= nearMissDistance
+}
+
+// logNearMiss writes a near miss event for the bomb that passed the ship
+// with the distance between their centers of miss.
+func (s *GameScene) logNearMiss(bomb *BombEntity, miss float32) {
+	bombLoc := bomb.GetLocation()
+	s.logEvent(&telemetry.Event{
+		Type:      telemetry.TypeNearMiss,
+		Entity:    bomb.GetName(),
+		Component: bomb.GetComponentName(),
+		Position:  &telemetry.Vec3{bombLoc[0], bombLoc[1], bombLoc[2]},
+		Miss:      float64(miss),
+	})
+}
+
+// logSpeedChange writes a speed event if the forward speed of the ship has
+// changed enough since the last one.
+func (s *GameScene) logSpeedChange() {
+	speed := float64(s.shipEntity.currentShipSpeed[2])
+	if math.Abs(speed-s.telemetry.lastSpeed) < speedChangeThreshold {
+		return
+	}
+	s.telemetry.lastSpeed = speed
+	s.logEvent(&telemetry.Event{
+		Type:  telemetry.TypeSpeed,
+		Speed: speed,
+	})
+}
+
+// sampleFrameTime adds the frame delta to the frame time samples and writes
+// a frame event once they cover frameSampleInterval seconds.
+func (s *GameScene) sampleFrameTime(frameDelta float32) {
+	if s.telemetry.writer == nil {
+		return
+	}
+
+	t := &s.telemetry
+	ms := float64(frameDelta) * 1000.0
+	t.frameCount++
+	t.frameTotal += ms
+	t.frameMax = math.Max(t.frameMax, ms)
+	t.frameInterval += float64(frameDelta)
+	if t.frameInterval < frameSampleInterval {
+		return
+	}
+
+	s.logEvent(&telemetry.Event{
+		Type: telemetry.TypeFrame,
+		Frames: &telemetry.FrameStats{
+			Count: t.frameCount,
+			AvgMs: t.frameTotal / float64(t.frameCount),
+			MaxMs: t.frameMax,
+		},
+	})
+	t.frameCount = 0
+	t.frameTotal = 0.0
+	t.frameMax = 0.0
+	t.frameInterval = 0.0
+}
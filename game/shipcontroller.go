@@ -0,0 +1,152 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"github.com/tbogdala/fizzle/scene"
+)
+
+const (
+	// the ship controller runs after the input systems have submitted
+	// their intents for the frame but before anything is rendered.
+	shipControllerPriority = -50.0
+	shipControllerName     = "ShipController"
+)
+
+// ShipIntent is a normalized request from an input device describing
+// how the player wants the ship to fly.
+type ShipIntent struct {
+	// Roll is in the range [-1..1] where positive values roll to the right.
+	Roll float32
+
+	// Pitch is in the range [-1..1] where positive values pitch the nose down.
+	Pitch float32
+
+	// Boost is in the range [0..1] and speeds the ship up.
+	Boost float32
+
+	// Absolute indicates that Roll and Pitch are the fraction of the maximum
+	// roll and pitch the ship should have. Otherwise they are the fraction
+	// of the turning speed to apply this frame.
+	Absolute bool
+}
+
+// ShipController implements the System interface and flies the player's
+// ship based on the intents submitted by the input systems each frame.
+// The flight model itself is implemented by ShipEntity.Fly().
+// Multiple input devices can submit intents in the same frame and they
+// will be merged together: rate intents are added up so that devices can
+// steer together, absolute intents are averaged so that two devices
+// targeting the same orientation don't double it, and the strongest boost
+// wins.
+type ShipController struct {
+	// OnShipMoved is a function that will get called each frame after the
+	// ship has been moved.
+	OnShipMoved func()
+
+	// merged intents for the current frame
+	rateRoll      float32
+	ratePitch     float32
+	absoluteRoll  float32
+	absolutePitch float32
+	absoluteCount int
+	boost         float32
+
+	// playerShipEntity is the cached reference to the player ship pawn.
+	playerShipEntity *ShipEntity
+
+	// gameScene is the scene the flights of the ship are recorded in.
+	gameScene *GameScene
+}
+
+// NewShipController creates a new ShipController object that flies the
+// player's ship in the game scene.
+func NewShipController(gs *GameScene) *ShipController {
+	c := new(ShipController)
+	c.gameScene = gs
+	return c
+}
+
+// AddIntent merges the intent from an input device into the intents
+// that will be applied to the ship this frame.
+func (c *ShipController) AddIntent(intent ShipIntent) {
+	if intent.Absolute {
+		c.absoluteRoll += intent.Roll
+		c.absolutePitch += intent.Pitch
+		c.absoluteCount++
+	} else {
+		c.rateRoll += intent.Roll
+		c.ratePitch += intent.Pitch
+	}
+	if intent.Boost > c.boost {
+		c.boost = intent.Boost
+	}
+}
+
+// clearIntents resets the merged intents for the next frame.
+func (c *ShipController) clearIntents() {
+	c.rateRoll = 0.0
+	c.ratePitch = 0.0
+	c.absoluteRoll = 0.0
+	c.absolutePitch = 0.0
+	c.absoluteCount = 0
+	c.boost = 0.0
+}
+
+// Update should get called to run updates for the system every frame
+// by the owning Manager object.
+func (c *ShipController) Update(frameDelta float32) {
+	defer c.clearIntents()
+
+	// if the game scene isn't simulating this frame, do not move the ship
+	if !c.gameScene.simulatingFrame || c.playerShipEntity == nil {
+		return
+	}
+
+	// fly the ship with the merged intents
+	controls := FlightControls{
+		RollRate:  c.rateRoll,
+		PitchRate: c.ratePitch,
+		Boost:     c.boost,
+	}
+	if c.absoluteCount > 0 {
+		controls.HasTarget = true
+		controls.TargetRoll = c.absoluteRoll / float32(c.absoluteCount)
+		controls.TargetPitch = c.absolutePitch / float32(c.absoluteCount)
+	}
+	c.playerShipEntity.Fly(controls, frameDelta)
+	c.gameScene.recordFrame(frameDelta, controls)
+
+	if c.OnShipMoved != nil {
+		c.OnShipMoved()
+	}
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (c *ShipController) OnAddEntity(newEntity scene.Entity) {
+	if newEntity.GetName() == PlayerShipEntityName {
+		c.playerShipEntity = newEntity.(*ShipEntity)
+	}
+}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (c *ShipController) OnRemoveEntity(oldEntity scene.Entity) {
+	if oldEntity.GetName() == PlayerShipEntityName {
+		c.playerShipEntity = nil
+	}
+}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (c *ShipController) GetRequestedPriority() float32 {
+	return shipControllerPriority
+}
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (c *ShipController) GetName() string {
+	return shipControllerName
+}
@@ -0,0 +1,219 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	component "github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/glider"
+)
+
+// max roll and pitch for the ship
+const (
+	maxRollRads  = math.Pi / 4.0 // 45 deg
+	maxPitchRads = math.Pi / 8.0 // 22.5 deg
+)
+
+// ShipHandling holds the tunable parameters of the flight model for a ship.
+// These can be overridden with the "Handling.*" properties of the ship's component.
+type ShipHandling struct {
+	// TurnRate scales how fast the ship rolls and pitches for rate controls
+	// like keypresses.
+	TurnRate float32
+
+	// AbsoluteTurnRate scales how fast the ship turns to match absolute
+	// controls like the orientation of a vive wand.
+	AbsoluteTurnRate float32
+
+	// LevelRate scales how fast the ship returns to level once the
+	// rate controls are released.
+	LevelRate float32
+
+	// Thrust is the lateral acceleration at full roll or pitch in m/s^2.
+	Thrust float32
+
+	// Damping is the fraction of lateral velocity lost per second.
+	Damping float32
+
+	// MaxLateralSpeed caps the lateral velocity in m/s.
+	MaxLateralSpeed float32
+
+	// CruiseSpeed is the forward speed of the ship in m/s.
+	CruiseSpeed float32
+
+	// BoostSpeed is added to the cruise speed at full boost.
+	BoostSpeed float32
+
+	// SpeedChangeRate is how fast the forward speed changes in m/s^2.
+	SpeedChangeRate float32
+}
+
+// FlightControls are the merged controls that fly the ship for a frame.
+type FlightControls struct {
+	// RollRate and PitchRate are in the range [-1..1] and turn the ship.
+	RollRate  float32
+	PitchRate float32
+
+	// HasTarget indicates that TargetRoll and TargetPitch should be used.
+	HasTarget bool
+
+	// TargetRoll and TargetPitch are in the range [-1..1] and are the
+	// fraction of the maximum roll and pitch the ship should turn towards.
+	TargetRoll  float32
+	TargetPitch float32
+
+	// Boost is in the range [0..1].
+	Boost float32
+}
+
+// ShipEntity is a scene entity for ships that fly in the game.
+type ShipEntity struct {
+	*VisibleEntity
+
+	// Handling is the flight model configuration for the ship.
+	Handling ShipHandling
+
+	// currentShipRoll is the current Roll rotation for the ship in radians.
+	currentShipRoll float32
+
+	// currentShipPitch is the current Pitch rotation for the ship in radians.
+	currentShipPitch float32
+
+	// currentShipSpeed is the forward speed of the ship.
+	currentShipSpeed mgl.Vec3 // m/s
+
+	// lateralVelocity is the velocity of the ship in the X/Y plane.
+	lateralVelocity mgl.Vec3 // m/s
+
+	// lateralAcceleration is the acceleration of the ship in the X/Y plane.
+	lateralAcceleration mgl.Vec3 // m/s^2
+}
+
+// NewShipHandling returns the default handling for ships.
+func NewShipHandling() ShipHandling {
+	return ShipHandling{
+		TurnRate:         1.5,
+		AbsoluteTurnRate: 8.0,
+		LevelRate:        1.0,
+		Thrust:           60.0,
+		Damping:          3.0,
+		MaxLateralSpeed:  25.0,
+		CruiseSpeed:      25.0,
+		BoostSpeed:       15.0,
+		SpeedChangeRate:  20.0,
+	}
+}
+
+// LoadFromComponent overrides the handling parameters with any
+// "Handling.*" properties set in the component.
+func (h *ShipHandling) LoadFromComponent(c *component.Component) error {
+	params := map[string]*float32{
+		"Handling.TurnRate":         &h.TurnRate,
+		"Handling.AbsoluteTurnRate": &h.AbsoluteTurnRate,
+		"Handling.LevelRate":        &h.LevelRate,
+		"Handling.Thrust":           &h.Thrust,
+		"Handling.Damping":          &h.Damping,
+		"Handling.MaxLateralSpeed":  &h.MaxLateralSpeed,
+		"Handling.CruiseSpeed":      &h.CruiseSpeed,
+		"Handling.BoostSpeed":       &h.BoostSpeed,
+		"Handling.SpeedChangeRate":  &h.SpeedChangeRate,
+	}
+	for key, param := range params {
+		value, okay := c.Properties[key]
+		if !okay {
+			continue
+		}
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		*param = float32(f)
+	}
+	return nil
+}
+
+// NewShipEntity returns a new ship entity object.
+func NewShipEntity() *ShipEntity {
+	se := new(ShipEntity)
+	se.VisibleEntity = NewVisibleEntity()
+	se.CollisionLayer = layerShip
+	se.Handling = NewShipHandling()
+	return se
+}
+
+// GetColliders should return all of the coarse colliders for an entity.
+func (s *ShipEntity) GetColliders() []glider.Collider {
+	return s.VisibleEntity.CoarseColliders
+}
+
+// GetVelocity returns the velocity of the ship relative to the grid: its
+// forward speed plus its lateral velocity.
+func (s *ShipEntity) GetVelocity() mgl.Vec3 {
+	return s.currentShipSpeed.Add(s.lateralVelocity)
+}
+
+// turnTowards moves current towards target by no more than maxStep.
+func turnTowards(current, target, maxStep float32) float32 {
+	return current + mgl.Clamp(target-current, -maxStep, maxStep)
+}
+
+// Fly runs the flight model for the ship for a frame. The controls turn
+// the ship and the roll and pitch of the ship accelerate it in the X/Y plane.
+func (s *ShipEntity) Fly(controls FlightControls, frameDelta float32) {
+	h := &s.Handling
+
+	// turn the ship towards any absolute targets
+	if controls.HasTarget {
+		targetRoll := mgl.Clamp(controls.TargetRoll, -1.0, 1.0) * maxRollRads
+		targetPitch := mgl.Clamp(controls.TargetPitch, -1.0, 1.0) * maxPitchRads
+		s.currentShipRoll = turnTowards(s.currentShipRoll, targetRoll, maxRollRads*frameDelta*h.AbsoluteTurnRate)
+		s.currentShipPitch = turnTowards(s.currentShipPitch, targetPitch, maxPitchRads*frameDelta*h.AbsoluteTurnRate)
+	}
+
+	// then turn the ship based on the rate controls, returning to level
+	// when they're released and there's no target to hold.
+	rollRate := mgl.Clamp(controls.RollRate, -1.0, 1.0)
+	if rollRate != 0.0 {
+		s.currentShipRoll += rollRate * maxRollRads * frameDelta * h.TurnRate
+	} else if !controls.HasTarget {
+		s.currentShipRoll = turnTowards(s.currentShipRoll, 0.0, maxRollRads*frameDelta*h.LevelRate)
+	}
+	pitchRate := mgl.Clamp(controls.PitchRate, -1.0, 1.0)
+	if pitchRate != 0.0 {
+		s.currentShipPitch += pitchRate * maxPitchRads * frameDelta * h.TurnRate
+	} else if !controls.HasTarget {
+		s.currentShipPitch = turnTowards(s.currentShipPitch, 0.0, maxPitchRads*frameDelta*h.LevelRate)
+	}
+	s.currentShipRoll = mgl.Clamp(s.currentShipRoll, -maxRollRads, maxRollRads)
+	s.currentShipPitch = mgl.Clamp(s.currentShipPitch, -maxPitchRads, maxPitchRads)
+
+	// rotate the ship
+	qRoll := mgl.QuatRotate(s.currentShipRoll, mgl.Vec3{0.0, 0.0, 1.0})
+	qPitch := mgl.QuatRotate(s.currentShipPitch, mgl.Vec3{1.0, 0.0, 0.0})
+	s.SetOrientation(qRoll.Mul(qPitch))
+
+	// the proportion of current roll/pitch to the maximum values
+	// determines the lateral thrust of the ship.
+	rollRatio := s.currentShipRoll / maxRollRads
+	pitchRatio := s.currentShipPitch / maxPitchRads
+	s.lateralAcceleration = mgl.Vec3{-h.Thrust * rollRatio, -h.Thrust * pitchRatio, 0.0}
+
+	// integrate the velocity and apply the damping
+	s.lateralVelocity = s.lateralVelocity.Add(s.lateralAcceleration.Mul(frameDelta))
+	s.lateralVelocity = s.lateralVelocity.Mul(float32(math.Exp(float64(-h.Damping * frameDelta))))
+	if speed := s.lateralVelocity.Len(); speed > h.MaxLateralSpeed {
+		s.lateralVelocity = s.lateralVelocity.Mul(h.MaxLateralSpeed / speed)
+	}
+
+	// move the ship
+	s.SetLocation(s.GetLocation().Add(s.lateralVelocity.Mul(frameDelta)))
+
+	// boosting speeds the ship up down the tunnel
+	targetSpeed := h.CruiseSpeed + h.BoostSpeed*mgl.Clamp(controls.Boost, 0.0, 1.0)
+	s.currentShipSpeed[2] = turnTowards(s.currentShipSpeed[2], targetSpeed, h.SpeedChangeRate*frameDelta)
+}
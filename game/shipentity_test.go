@@ -0,0 +1,124 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"math"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const testFrameDelta = 1.0 / 60.0
+
+func TestShipFlight(t *testing.T) {
+	undamped := NewShipHandling()
+	undamped.Damping = 0.0
+
+	tests := []struct {
+		name     string
+		handling ShipHandling
+		start    func(s *ShipEntity)
+		controls FlightControls
+		frames   int
+
+		// each is checked after every frame; done returns true once the
+		// ship has done what's expected, which has to happen by the last frame.
+		each func(s *ShipEntity, prev *ShipEntity) string
+		done func(s *ShipEntity) bool
+	}{
+		{
+			name:     "levels out with no input",
+			handling: NewShipHandling(),
+			start: func(s *ShipEntity) {
+				s.currentShipRoll = maxRollRads
+				s.currentShipPitch = -maxPitchRads
+			},
+			// turning back at LevelRate takes 1/LevelRate seconds
+			frames: int(math.Ceil(1.0/float64(NewShipHandling().LevelRate)/testFrameDelta)) + 1,
+			each: func(s *ShipEntity, prev *ShipEntity) string {
+				if math.Abs(float64(s.currentShipRoll)) > math.Abs(float64(prev.currentShipRoll)) {
+					return "the roll moved away from level"
+				}
+				return ""
+			},
+			done: func(s *ShipEntity) bool {
+				return s.currentShipRoll == 0.0 && s.currentShipPitch == 0.0
+			},
+		},
+		{
+			name:     "damping slows the ship",
+			handling: NewShipHandling(),
+			start: func(s *ShipEntity) {
+				s.lateralVelocity = mgl.Vec3{10.0, -5.0, 0.0}
+			},
+			frames: 60,
+			each: func(s *ShipEntity, prev *ShipEntity) string {
+				if s.lateralVelocity.Len() >= prev.lateralVelocity.Len() {
+					return "the lateral speed didn't decay"
+				}
+				return ""
+			},
+			done: func(s *ShipEntity) bool {
+				// a second of damping leaves exp(-Damping) of the speed
+				expected := mgl.Vec3{10.0, -5.0, 0.0}.Len() * float32(math.Exp(-float64(s.Handling.Damping)))
+				return math.Abs(float64(s.lateralVelocity.Len()-expected)) < 0.01
+			},
+		},
+		{
+			name:     "lateral speed is capped",
+			handling: undamped,
+			controls: FlightControls{RollRate: 1.0, PitchRate: -1.0},
+			frames:   600,
+			each: func(s *ShipEntity, prev *ShipEntity) string {
+				if s.lateralVelocity.Len() > s.Handling.MaxLateralSpeed+1e-3 {
+					return "the lateral speed passed the cap"
+				}
+				return ""
+			},
+			done: func(s *ShipEntity) bool {
+				return math.Abs(float64(s.lateralVelocity.Len()-s.Handling.MaxLateralSpeed)) < 1e-3
+			},
+		},
+		{
+			name:     "reaches an absolute target",
+			handling: NewShipHandling(),
+			controls: FlightControls{HasTarget: true, TargetRoll: -1.0, TargetPitch: 0.5},
+			// the largest turn is the full roll at AbsoluteTurnRate
+			frames: int(math.Ceil(1.0/float64(NewShipHandling().AbsoluteTurnRate)/testFrameDelta)) + 1,
+			each: func(s *ShipEntity, prev *ShipEntity) string {
+				if s.currentShipRoll > prev.currentShipRoll {
+					return "the roll turned away from the target"
+				}
+				return ""
+			},
+			done: func(s *ShipEntity) bool {
+				return s.currentShipRoll == -maxRollRads && s.currentShipPitch == 0.5*maxPitchRads
+			},
+		},
+	}
+
+	for _, test := range tests {
+		ship := NewShipEntity()
+		ship.Handling = test.handling
+		if test.start != nil {
+			test.start(ship)
+		}
+
+		done := false
+		for i := 0; i < test.frames; i++ {
+			prev := *ship
+			ship.Fly(test.controls, testFrameDelta)
+			if problem := test.each(ship, &prev); problem != "" {
+				t.Errorf("%s: frame %d: %s", test.name, i, problem)
+				break
+			}
+			done = test.done(ship)
+		}
+		if !done {
+			t.Errorf("%s: not done after %d frames: roll %f, pitch %f, lateral velocity %v", test.name, test.frames,
+				ship.currentShipRoll, ship.currentShipPitch, ship.lateralVelocity)
+		}
+	}
+}
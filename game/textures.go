@@ -0,0 +1,259 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	component "github.com/tbogdala/fizzle/component"
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// The texture quality tiers. Each one is a directory under a textures
+// directory holding the same set of textures at a different resolution.
+const (
+	TextureQualityHigh = "2k"
+	TextureQualityLow  = "512"
+
+	// textureDirName is the name of the directory holding the tier directories.
+	textureDirName = "textures"
+)
+
+var (
+	// textureQualities are the tiers from the highest quality to the lowest.
+	textureQualities = []string{TextureQualityHigh, TextureQualityLow}
+)
+
+// isTextureQuality returns true if the quality is one of the known tiers.
+func isTextureQuality(quality string) bool {
+	return textureQualityIndex(quality) >= 0
+}
+
+// textureQualityIndex returns the index of the quality in textureQualities
+// or -1 if it's unknown.
+func textureQualityIndex(quality string) int {
+	for i, q := range textureQualities {
+		if q == quality {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextTextureQuality returns the tier after quality, wrapping around to the
+// highest quality after the lowest.
+func nextTextureQuality(quality string) string {
+	i := textureQualityIndex(quality)
+	return textureQualities[(i+1)%len(textureQualities)]
+}
+
+// resolveTexturePath returns the texture reference with its tier directory
+// changed to quality. If the texture doesn't exist in that tier, the nearest
+// tier that has it is used instead. References that aren't in a tier
+// directory are returned unchanged, as are ones no tier has.
+func resolveTexturePath(componentDir string, texture string, quality string) string {
+	parts := strings.Split(filepath.ToSlash(texture), "/")
+	tierPart := -1
+	for i := 1; i < len(parts); i++ {
+		if parts[i-1] == textureDirName && isTextureQuality(parts[i]) {
+			tierPart = i
+			break
+		}
+	}
+	if tierPart < 0 {
+		return texture
+	}
+
+	// try the tiers in order of how far they are from the one requested,
+	// preferring the lower quality of two equally near tiers.
+	wanted := textureQualityIndex(quality)
+	if wanted < 0 {
+		wanted = textureQualityIndex(parts[tierPart])
+	}
+	for distance := 0; distance < len(textureQualities); distance++ {
+		for _, i := range []int{wanted + distance, wanted - distance} {
+			if i < 0 || i >= len(textureQualities) {
+				continue
+			}
+			parts[tierPart] = textureQualities[i]
+			candidate := strings.Join(parts, "/")
+			if _, err := os.Stat(filepath.Join(componentDir, candidate)); err == nil {
+				return candidate
+			}
+			if distance == 0 {
+				break
+			}
+		}
+	}
+	return texture
+}
+
+// textureRefs returns pointers to the texture references of the component's
+// materials, including the ones that are empty.
+func textureRefs(c *component.Component) []*string {
+	var refs []*string
+	for _, compMesh := range c.Meshes {
+		refs = append(refs,
+			&compMesh.Material.DiffuseTexture,
+			&compMesh.Material.NormalsTexture,
+			&compMesh.Material.SpecularTexture)
+		for i := range compMesh.Material.Textures {
+			refs = append(refs, &compMesh.Material.Textures[i])
+		}
+	}
+	return refs
+}
+
+// applyTextureQuality changes the texture references of the component's
+// materials to the quality tier. It returns true if any of them changed.
+func applyTextureQuality(c *component.Component, componentDir string, quality string) bool {
+	changed := false
+	for _, texture := range textureRefs(c) {
+		if *texture == "" {
+			continue
+		}
+		resolved := resolveTexturePath(componentDir, *texture, quality)
+		if resolved != *texture {
+			*texture = resolved
+			changed = true
+		}
+	}
+	return changed
+}
+
+// loadComponent loads the component file into the component manager with
+// its textures resolved against the texture quality of the scene.
+func (s *GameScene) loadComponent(cm *component.Manager, filename string, name string) (*component.Component, error) {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := new(component.Component)
+	err = json.Unmarshal(jsonBytes, c)
+	if err != nil {
+		return nil, err
+	}
+
+	componentDir, _ := filepath.Split(filename)
+	if applyTextureQuality(c, componentDir, s.TextureQuality) {
+		jsonBytes, err = json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cm.LoadComponentFromBytes(jsonBytes, name, componentDir)
+}
+
+// SetTextureQuality switches the textures of the loaded components to the
+// quality tier, loading them through the texture manager, and rebuilds the
+// renderables of the entities using them. The textures of the previous tier
+// stay loaded so that switching back is quick. If any of the textures fails
+// to load, the components are left on the tier they were on.
+func (s *GameScene) SetTextureQuality(quality string) error {
+	if !isTextureQuality(quality) {
+		return fmt.Errorf("unknown texture quality %q, expected one of %s",
+			quality, strings.Join(textureQualities, ", "))
+	}
+	if s.components == nil {
+		s.TextureQuality = quality
+		return nil
+	}
+
+	// the references of the components changed so far, to put back if a
+	// texture fails to load
+	previousRefs := make(map[string][]string)
+	rollback := func() {
+		for name, previous := range previousRefs {
+			c, _ := s.components.GetComponent(name)
+			for i, texture := range textureRefs(c) {
+				*texture = previous[i]
+			}
+		}
+	}
+
+	changedComponents := make(map[string]bool)
+	for name, filename := range s.componentFiles {
+		c, okay := s.components.GetComponent(name)
+		if !okay {
+			continue
+		}
+		var previous []string
+		for _, texture := range textureRefs(c) {
+			previous = append(previous, *texture)
+		}
+		componentDir, _ := filepath.Split(filename)
+		if !applyTextureQuality(c, componentDir, quality) {
+			continue
+		}
+		previousRefs[name] = previous
+
+		for _, texture := range textureRefs(c) {
+			if *texture == "" {
+				continue
+			}
+			if _, loaded := s.textureMan.GetTexture(*texture); loaded {
+				continue
+			}
+			_, err := s.textureMan.LoadTexture(*texture, componentDir+*texture)
+			if err != nil {
+				rollback()
+				return fmt.Errorf("failed to load the texture %s for %s: %v", *texture, name, err)
+			}
+		}
+		changedComponents[name] = true
+	}
+
+	s.TextureQuality = quality
+	s.rebuildRenderables(changedComponents)
+	return nil
+}
+
+// CycleTextureQuality switches to the next texture quality tier and returns it.
+func (s *GameScene) CycleTextureQuality() string {
+	quality := nextTextureQuality(s.TextureQuality)
+	err := s.SetTextureQuality(quality)
+	if err != nil {
+		fmt.Printf("Failed to change the texture quality. %v\n", err)
+	}
+	return s.TextureQuality
+}
+
+// rebuildRenderables replaces the renderables of the entities created from
+// the components named with new instances of the components.
+func (s *GameScene) rebuildRenderables(componentNames map[string]bool) {
+	if len(componentNames) == 0 || s.headless {
+		return
+	}
+	s.MapEntities(func(id uint64, e scene.Entity) {
+		ce, okay := e.(ComponentEntity)
+		if !okay || !componentNames[ce.GetComponentName()] {
+			return
+		}
+		re, okay := e.(RenderableEntity)
+		if !okay || re.GetRenderable() == nil {
+			return
+		}
+		c, okay := s.components.GetComponent(ce.GetComponentName())
+		if !okay {
+			return
+		}
+
+		setter, okay := e.(RenderableSetter)
+		if !okay {
+			return
+		}
+
+		old := re.GetRenderable()
+		r := s.components.GetRenderableInstance(c)
+		r.Location = old.Location
+		r.LocalRotation = old.LocalRotation
+		r.Scale = old.Scale
+		setter.SetRenderable(r)
+	})
+}
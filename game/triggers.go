@@ -0,0 +1,180 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	scene "github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+// The tags that say what a collider on the trigger layer does when the ship
+// flies into it. A trigger can have any number of them.
+const (
+	// triggerRingTag is a fly-through ring that adds to the score.
+	triggerRingTag = "ring"
+
+	// triggerCheckpointTag is a checkpoint gate.
+	triggerCheckpointTag = "checkpoint"
+
+	// triggerBonusTagPrefix sets the score of a ring, such as "bonus:250".
+	triggerBonusTagPrefix = "bonus:"
+
+	// triggerEventTagPrefix names the scripted event a zone starts, such
+	// as "event:swarm".
+	triggerEventTagPrefix = "event:"
+)
+
+// ringBonusScore is what a ring adds to the score without a bonus tag.
+const ringBonusScore = 100
+
+// TriggerEvent is the ship entering or leaving a trigger collider.
+type TriggerEvent struct {
+	// Entity owns the trigger and Collider is the index of the trigger in
+	// its colliders.
+	Entity   scene.Entity
+	Collider int
+
+	// Tags are the tags of the trigger collider.
+	Tags []string
+
+	// Entered is true when the ship entered the trigger and false when it left.
+	Entered bool
+}
+
+// ScriptedEvent returns the name of the scripted event the trigger starts,
+// if it's a zone for one.
+func (e *TriggerEvent) ScriptedEvent() (string, bool) {
+	return tagValue(e.Tags, triggerEventTagPrefix)
+}
+
+// TaggedCollisionEntity is an entity that keeps the tags of its colliders.
+type TaggedCollisionEntity interface {
+	// GetColliderTags returns the tags of each of the colliders returned
+	// by GetColliders.
+	GetColliderTags() [][]string
+}
+
+// triggerKey identifies a trigger collider of an entity.
+type triggerKey struct {
+	entity   uint64
+	collider int
+}
+
+// tagValue returns the rest of the first tag starting with the prefix.
+func tagValue(tags []string, prefix string) (string, bool) {
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, prefix) {
+			return strings.TrimPrefix(tag, prefix), true
+		}
+	}
+	return "", false
+}
+
+// hasTag returns true if the tag is in the list.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// onShipInTrigger handles the ship overlapping a trigger by noting that
+// it's inside of it this frame.
+func (s *GameScene) onShipInTrigger(event *CollisionEvent) {
+	if event.A.GetID() != s.shipEntity.ID {
+		return
+	}
+	s.triggersTouched[triggerKey{event.B.GetID(), event.ColliderB}] = event.B
+}
+
+// updateTriggers compares the triggers the ship is inside of this frame with
+// the last frame's and fires the enter and exit events. A trigger whose
+// entity was removed counts as left.
+func (s *GameScene) updateTriggers() {
+	for _, key := range sortedTriggerKeys(s.triggersTouched) {
+		if _, okay := s.triggersInside[key]; !okay {
+			s.fireTrigger(s.triggersTouched[key], key.collider, true)
+		}
+	}
+	for _, key := range sortedTriggerKeys(s.triggersInside) {
+		if _, okay := s.triggersTouched[key]; !okay {
+			s.fireTrigger(s.triggersInside[key], key.collider, false)
+		}
+	}
+	s.triggersInside, s.triggersTouched = s.triggersTouched, s.triggersInside
+	for key := range s.triggersTouched {
+		delete(s.triggersTouched, key)
+	}
+}
+
+// colliderTags returns the tags of a collider of the entity.
+func colliderTags(e scene.Entity, collider int) []string {
+	if te, okay := e.(TaggedCollisionEntity); okay {
+		if tags := te.GetColliderTags(); collider < len(tags) {
+			return tags[collider]
+		}
+	}
+	return nil
+}
+
+// tagBonus returns the score in the bonus tag or the default if there isn't one.
+func tagBonus(tags []string, bonus int64) int64 {
+	if value, okay := tagValue(tags, triggerBonusTagPrefix); okay {
+		if b, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return b
+		}
+	}
+	return bonus
+}
+
+// fireTrigger runs what the trigger does for the ship entering or leaving
+// it and then publishes the event.
+func (s *GameScene) fireTrigger(owner scene.Entity, collider int, entered bool) {
+	event := &TriggerEvent{Entity: owner, Collider: collider, Entered: entered}
+	event.Tags = colliderTags(owner, collider)
+
+	eventType := telemetry.TypeTriggerExit
+	if entered {
+		eventType = telemetry.TypeTriggerEnter
+	}
+	logged := newEntityEvent(eventType, owner, s.shipEntity.GetLocation())
+	logged.Tags = event.Tags
+	logged.ScriptedEvent, _ = event.ScriptedEvent()
+
+	if entered && hasTag(event.Tags, triggerRingTag) {
+		logged.Bonus = tagBonus(event.Tags, ringBonusScore)
+		s.bonusScore += logged.Bonus
+		s.ShowToast("RING", fmt.Sprintf("+%d", logged.Bonus))
+	}
+	s.logEvent(logged)
+	if entered && hasTag(event.Tags, triggerCheckpointTag) {
+		s.checkpointsPassed++
+		s.ShowToast("CHECKPOINT", fmt.Sprintf("Checkpoint %d", s.checkpointsPassed))
+	}
+	s.Events.PublishTrigger(event)
+}
+
+// sortedTriggerKeys returns the keys ordered by entity id and then collider
+// so that triggers fire in the same order every time a run is simulated.
+func sortedTriggerKeys(m map[triggerKey]scene.Entity) []triggerKey {
+	keys := make([]triggerKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].entity != keys[j].entity {
+			return keys[i].entity < keys[j].entity
+		}
+		return keys[i].collider < keys[j].collider
+	})
+	return keys
+}
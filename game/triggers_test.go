@@ -0,0 +1,41 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func TestTriggerEnterExit(t *testing.T) {
+	s := newCollisionTestScene(t)
+	ship := s.shipEntity.GetLocation()
+	ring := addTaggedEntity(s, "Ring", ship.Add(mgl.Vec3{0.0, 0.0, 3.0}), 0.5, "trigger", "ring", "bonus:250")
+
+	var fired []bool
+	s.Events.OnTrigger(func(e *TriggerEvent) {
+		if e.Entity != ring {
+			t.Errorf("a trigger fired for %s", e.Entity.GetName())
+		}
+		fired = append(fired, e.Entered)
+	})
+
+	// fly the ring past the ship a little at a time
+	for z := float32(3.0); z >= -3.0; z -= 0.25 {
+		ring.SetLocation(ship.Add(mgl.Vec3{0.0, 0.0, z}))
+		s.checkCollisions()
+		s.updateTriggers()
+	}
+
+	if len(fired) != 2 || !fired[0] || fired[1] {
+		t.Errorf("expected the ring to be entered then left once, got %v", fired)
+	}
+	if s.bonusScore != 250 {
+		t.Errorf("expected the ring to add its bonus of 250, got %d", s.bonusScore)
+	}
+	if s.crash != nil {
+		t.Errorf("the trigger reached the crash handler")
+	}
+}
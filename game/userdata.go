@@ -0,0 +1,42 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	userDataDirName = "infinigrid"
+)
+
+// UserDataDir returns the directory used to store the player's data,
+// such as the high score table, creating it if necessary.
+func UserDataDir() (string, error) {
+	var baseDir string
+	switch runtime.GOOS {
+	case "windows":
+		baseDir = os.Getenv("APPDATA")
+	case "darwin":
+		baseDir = filepath.Join(os.Getenv("HOME"), "Library", "Application Support")
+	default:
+		baseDir = os.Getenv("XDG_DATA_HOME")
+		if baseDir == "" {
+			baseDir = filepath.Join(os.Getenv("HOME"), ".local", "share")
+		}
+	}
+	if baseDir == "" {
+		return "", fmt.Errorf("unable to determine the user data directory")
+	}
+
+	dataDir := filepath.Join(baseDir, userDataDirName)
+	err := os.MkdirAll(dataDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the user data directory: %v", err)
+	}
+	return dataDir, nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/tbogdala/infinigrid/leaderboard"
+	"github.com/tbogdala/infinigrid/replay"
+	"github.com/tbogdala/infinigrid/telemetry"
+)
+
+const (
+	// verifyDistanceTolerance is how far, in meters, the recomputed distance
+	// may be from the claimed distance. Floating point math isn't bit exact
+	// across machines so a little slack is allowed.
+	verifyDistanceTolerance = 0.5
+
+	// MaxFrameDelta is the longest frame allowed in a replay; anything
+	// longer would let the ship skip through bombs. The game loop clamps
+	// its frames to it so that slow frames don't spoil honest replays.
+	MaxFrameDelta = 0.25
+
+	// MinFrameDelta is the shortest frame the game loop plays; it waits out
+	// faster frames so that a replay has at most replay.MaxFrames frames in
+	// MaxRunDuration.
+	MinFrameDelta = 1.0 / 500.0
+
+	// MaxRunDuration is the longest run, in seconds, a replay may hold.
+	MaxRunDuration = 60.0 * 60.0
+
+	// DefaultVerifyTimeout is how long the LeaderboardVerifier spends
+	// re-simulating a run before giving up on it.
+	DefaultVerifyTimeout = 30 * time.Second
+
+	// verifyDeadlineFrames is how many frames are simulated between checks
+	// of the verification deadline.
+	verifyDeadlineFrames = 1000
+)
+
+// RunClaim is what a player claims happened in a run.
+type RunClaim struct {
+	Seed     int64
+	Mode     string
+	Score    int64
+	Distance float64
+}
+
+// VerifyReport describes the result of re-simulating a run from its replay.
+type VerifyReport struct {
+	// Accepted is true if the re-simulated run matches the claim.
+	Accepted bool
+
+	Claim RunClaim
+
+	// Score and Distance are the recomputed values.
+	Score    int64
+	Distance float64
+
+	// TotalFrames is the number of frames in the replay and DeathFrame is
+	// the frame the ship died on, or -1 if it survived the whole replay.
+	TotalFrames int
+	DeathFrame  int
+
+	// DeathTime is the game time of the death in seconds.
+	DeathTime float64
+
+	// DeathCause is the name of the entity the ship collided with.
+	DeathCause string
+
+	// Mismatches lists every difference between the claim and the replay.
+	Mismatches []string
+}
+
+// String returns a human readable report.
+func (r *VerifyReport) String() string {
+	var buf bytes.Buffer
+	if r.Accepted {
+		fmt.Fprintf(&buf, "ACCEPTED\n")
+	} else {
+		fmt.Fprintf(&buf, "REJECTED\n")
+	}
+	fmt.Fprintf(&buf, "  seed:     %d (%s)\n", r.Claim.Seed, r.Claim.Mode)
+	fmt.Fprintf(&buf, "  score:    claimed %d, recomputed %d\n", r.Claim.Score, r.Score)
+	fmt.Fprintf(&buf, "  distance: claimed %.2f, recomputed %.2f\n", r.Claim.Distance, r.Distance)
+	if r.DeathFrame >= 0 {
+		fmt.Fprintf(&buf, "  death:    frame %d of %d at %.2fs hitting %s\n", r.DeathFrame, r.TotalFrames, r.DeathTime, r.DeathCause)
+	} else {
+		fmt.Fprintf(&buf, "  death:    none in %d frames\n", r.TotalFrames)
+	}
+	for _, m := range r.Mismatches {
+		fmt.Fprintf(&buf, "  mismatch: %s\n", m)
+	}
+	return buf.String()
+}
+
+// newReplayScene creates a headless game scene set up to re-simulate the
+// replay. If telemetryOutput is non-nil the event log of the run is written to it.
+func newReplayScene(rep *replay.Replay, telemetryOutput io.Writer) (*GameScene, error) {
+	s := NewHeadlessGameScene()
+	s.GameMode = rep.Mode
+	s.FixedSeed = rep.Seed
+	s.UseFixedSeed = true
+	s.telemetry.output = telemetryOutput
+	err := s.SetupScene()
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup the headless scene: %v", err)
+	}
+	return s, nil
+}
+
+// simulateReplayFrame runs a frame of a replay through the same steps as
+// GameScene.Update with the ShipController flying the ship.
+func (s *GameScene) simulateReplayFrame(f replay.Frame) {
+	s.currentFrameDelta = f.Delta
+	s.simulatingFrame = true
+	s.currentGameTime += float64(f.Delta)
+
+	s.shipEntity.Fly(FlightControls{
+		RollRate:    f.RollRate,
+		PitchRate:   f.PitchRate,
+		HasTarget:   f.HasTarget,
+		TargetRoll:  f.TargetRoll,
+		TargetPitch: f.TargetPitch,
+		Boost:       f.Boost,
+	}, f.Delta)
+	s.simulateFrame(f.Delta)
+}
+
+func (r *VerifyReport) addMismatch(format string, a ...interface{}) {
+	r.Mismatches = append(r.Mismatches, fmt.Sprintf(format, a...))
+}
+
+// checkReplayFrame returns an error if the frame can't be from a run of the
+// game, given the game time of the frames before it.
+func checkReplayFrame(f replay.Frame, elapsed float64) error {
+	if !(f.Delta > 0.0 && f.Delta <= MaxFrameDelta) {
+		return fmt.Errorf("an invalid frame delta of %f", f.Delta)
+	}
+	if elapsed+float64(f.Delta) > MaxRunDuration {
+		return fmt.Errorf("a game time of more than %.0f seconds", MaxRunDuration)
+	}
+	return nil
+}
+
+// VerifyTimeoutError is returned by VerifyRunBefore if the run couldn't be
+// re-simulated before the deadline.
+type VerifyTimeoutError struct {
+	// Frame is the frame the simulation had reached.
+	Frame int
+}
+
+func (e *VerifyTimeoutError) Error() string {
+	return fmt.Sprintf("ran out of time re-simulating the replay at frame %d", e.Frame)
+}
+
+// VerifyRun re-simulates the run recorded in the replay with a headless game
+// scene and checks it against the claim. The run is only accepted if the ship
+// dies on the last recorded frame and the recomputed distance matches the
+// claim. An error is returned if the run couldn't be simulated at all.
+func VerifyRun(rep *replay.Replay, claim RunClaim) (*VerifyReport, error) {
+	return VerifyRunBefore(rep, claim, time.Time{})
+}
+
+// VerifyRunBefore is VerifyRun giving up with a *VerifyTimeoutError if the
+// run hasn't been re-simulated by the deadline. A zero deadline never passes.
+func VerifyRunBefore(rep *replay.Replay, claim RunClaim, deadline time.Time) (*VerifyReport, error) {
+	report := new(VerifyReport)
+	report.Claim = claim
+	report.TotalFrames = len(rep.Frames)
+	report.DeathFrame = -1
+
+	if rep.Seed != claim.Seed {
+		report.addMismatch("the replay seed %d doesn't match the claimed seed %d", rep.Seed, claim.Seed)
+	}
+	if rep.Mode != claim.Mode {
+		report.addMismatch("the replay mode %q doesn't match the claimed mode %q", rep.Mode, claim.Mode)
+	}
+
+	s, err := newReplayScene(rep, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range rep.Frames {
+		err = checkReplayFrame(f, s.currentGameTime)
+		if err != nil {
+			report.addMismatch("frame %d has %v", i, err)
+			break
+		}
+		if !deadline.IsZero() && i%verifyDeadlineFrames == 0 && time.Now().After(deadline) {
+			return nil, &VerifyTimeoutError{Frame: i}
+		}
+
+		s.simulateReplayFrame(f)
+
+		if s.gameState == GameStatePlayerDied {
+			report.DeathFrame = i
+			report.DeathTime = s.currentGameTime
+			report.DeathCause = s.deathCause
+			break
+		}
+	}
+
+	report.Score = s.Score()
+	report.Distance = s.distanceTravelled
+
+	if report.DeathFrame < 0 {
+		report.addMismatch("the ship never died during the replay")
+	} else if report.DeathFrame != report.TotalFrames-1 {
+		report.addMismatch("the ship died on frame %d but the replay has %d frames", report.DeathFrame, report.TotalFrames)
+	}
+	// the score is the whole distance plus the bonus score from the triggers,
+	// which is recomputed exactly, so it's checked against the claimed
+	// distance, which is allowed the tolerance, rather than the recomputed one.
+	if claim.Score != int64(claim.Distance)+s.bonusScore {
+		report.addMismatch("the claimed score %d doesn't match the claimed distance %.2f and the bonus score %d",
+			claim.Score, claim.Distance, s.bonusScore)
+	}
+	if math.Abs(report.Distance-claim.Distance) > verifyDistanceTolerance {
+		report.addMismatch("the claimed distance %.2f doesn't match the recomputed distance %.2f", claim.Distance, report.Distance)
+	}
+
+	report.Accepted = len(report.Mismatches) == 0
+	return report, nil
+}
+
+// ReplayEvents re-simulates the replay headless and returns its event log.
+func ReplayEvents(rep *replay.Replay) ([]telemetry.Event, error) {
+	var log bytes.Buffer
+	s, err := newReplayScene(rep, &log)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range rep.Frames {
+		err = checkReplayFrame(f, s.currentGameTime)
+		if err != nil {
+			s.CloseTelemetry()
+			return nil, fmt.Errorf("frame %d of the replay has %v", i, err)
+		}
+		s.simulateReplayFrame(f)
+		if s.gameState == GameStatePlayerDied {
+			break
+		}
+	}
+	s.CloseTelemetry()
+	return telemetry.Read(&log)
+}
+
+// LeaderboardVerifier implements leaderboard.Verifier by re-simulating the
+// submitted runs from their replays with VerifyRun.
+type LeaderboardVerifier struct {
+	// Timeout is how long a run may take to re-simulate before it's
+	// treated as unverifiable. Zero uses DefaultVerifyTimeout.
+	Timeout time.Duration
+}
+
+// Verify checks the claim of the submission against its replay.
+func (v *LeaderboardVerifier) Verify(sub *leaderboard.Submission) error {
+	rep, err := replay.Decode(sub.Replay)
+	if err != nil {
+		return &leaderboard.UnverifiableError{Report: fmt.Sprintf("failed to read the replay: %v", err)}
+	}
+
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	report, err := VerifyRunBefore(rep, RunClaim{
+		Seed:     sub.Seed,
+		Mode:     sub.Mode,
+		Score:    sub.Score,
+		Distance: sub.Distance,
+	}, time.Now().Add(timeout))
+	if terr, okay := err.(*VerifyTimeoutError); okay {
+		return &leaderboard.UnverifiableError{Report: terr.Error()}
+	} else if err != nil {
+		return err
+	}
+	if !report.Accepted {
+		return &leaderboard.RejectedError{Report: report.String()}
+	}
+	return nil
+}
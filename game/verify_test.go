@@ -0,0 +1,167 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbogdala/infinigrid/replay"
+)
+
+const (
+	// testRunSeed is the seed of the recorded test runs.
+	testRunSeed = 42
+
+	// testRunMaxFrames is how long a recorded test run is flown for before
+	// giving up on the ship dying.
+	testRunMaxFrames = 60 * 60 * 10
+)
+
+// recordTestRun flies a headless run with the test components, weaving the
+// ship from side to side until it dies, and returns its replay along with
+// the honest claim for it.
+func recordTestRun(t *testing.T) (*replay.Replay, RunClaim) {
+	AssetRoots = []string{filepath.Join("testdata", DefaultAssetRoot)}
+	s := NewHeadlessGameScene()
+	s.UseFixedSeed = true
+	s.FixedSeed = testRunSeed
+	err := s.SetupScene()
+	if err != nil {
+		t.Fatalf("failed to set up the scene: %v", err)
+	}
+
+	rep := replay.New(testRunSeed, s.GameMode)
+	for i := 0; i < testRunMaxFrames; i++ {
+		f := replay.Frame{
+			Delta:    1.0 / 60.0,
+			RollRate: float32(math.Sin(float64(i) / 40.0)),
+			Boost:    0.5,
+		}
+		s.simulateReplayFrame(f)
+		rep.AddFrame(f)
+		if s.gameState == GameStatePlayerDied {
+			return rep, RunClaim{
+				Seed:     testRunSeed,
+				Mode:     s.GameMode,
+				Score:    s.Score(),
+				Distance: s.distanceTravelled,
+			}
+		}
+	}
+	t.Fatalf("the ship didn't die in %d frames", testRunMaxFrames)
+	return nil, RunClaim{}
+}
+
+// copyReplay returns a copy of the replay whose frames can be changed.
+func copyReplay(rep *replay.Replay) *replay.Replay {
+	c := replay.New(rep.Seed, rep.Mode)
+	c.Frames = append([]replay.Frame(nil), rep.Frames...)
+	return c
+}
+
+// hasMismatch returns true if any of the mismatches of the report contain the text.
+func hasMismatch(report *VerifyReport, text string) bool {
+	for _, m := range report.Mismatches {
+		if strings.Contains(m, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVerifyRun(t *testing.T) {
+	rep, claim := recordTestRun(t)
+
+	report, err := VerifyRun(rep, claim)
+	if err != nil {
+		t.Fatalf("failed to verify the run: %v", err)
+	}
+	if !report.Accepted {
+		t.Fatalf("the honest run was rejected:\n%s", report)
+	}
+	if report.DeathFrame != len(rep.Frames)-1 {
+		t.Errorf("the ship died on frame %d of %d", report.DeathFrame, len(rep.Frames))
+	}
+
+	truncated := copyReplay(rep)
+	truncated.Frames = truncated.Frames[:len(truncated.Frames)-10]
+
+	tests := []struct {
+		name     string
+		replay   *replay.Replay
+		claim    func(c RunClaim) RunClaim
+		mismatch string
+	}{
+		{"inflated distance", rep, func(c RunClaim) RunClaim {
+			c.Distance += 10.0
+			c.Score += 10
+			return c
+		}, "doesn't match the recomputed distance"},
+		{"wrong seed", rep, func(c RunClaim) RunClaim {
+			c.Seed++
+			return c
+		}, "doesn't match the claimed seed"},
+		{"wrong mode", rep, func(c RunClaim) RunClaim {
+			c.Mode = GameModeDaily
+			return c
+		}, "doesn't match the claimed mode"},
+		{"truncated frames", truncated, func(c RunClaim) RunClaim {
+			return c
+		}, "the ship never died"},
+	}
+
+	for _, test := range tests {
+		report, err := VerifyRun(test.replay, test.claim(claim))
+		if err != nil {
+			t.Errorf("%s: failed to verify the run: %v", test.name, err)
+			continue
+		}
+		if report.Accepted {
+			t.Errorf("%s: the run was accepted", test.name)
+		}
+		if !hasMismatch(report, test.mismatch) {
+			t.Errorf("%s: expected a mismatch with %q, got %v", test.name, test.mismatch, report.Mismatches)
+		}
+	}
+}
+
+func TestVerifyRunFrameDeltas(t *testing.T) {
+	rep, claim := recordTestRun(t)
+
+	tests := map[string]float32{
+		"negative": -1.0 / 60.0,
+		"zero":     0.0,
+		"NaN":      float32(math.NaN()),
+		"too long": MaxFrameDelta * 2.0,
+	}
+	for name, delta := range tests {
+		bad := copyReplay(rep)
+		bad.Frames[5].Delta = delta
+
+		report, err := VerifyRun(bad, claim)
+		if err != nil {
+			t.Errorf("%s: failed to verify the run: %v", name, err)
+			continue
+		}
+		if report.Accepted {
+			t.Errorf("%s: the run was accepted", name)
+		}
+		if !hasMismatch(report, "frame 5 has an invalid frame delta") {
+			t.Errorf("%s: expected an invalid frame delta, got %v", name, report.Mismatches)
+		}
+	}
+}
+
+func TestVerifyRunDeadline(t *testing.T) {
+	rep, claim := recordTestRun(t)
+
+	_, err := VerifyRunBefore(rep, claim, time.Now().Add(-time.Second))
+	if _, okay := err.(*VerifyTimeoutError); !okay {
+		t.Errorf("expected a *VerifyTimeoutError for a deadline that passed, got %v", err)
+	}
+}
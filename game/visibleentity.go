@@ -0,0 +1,373 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/glider"
+
+	fizzle "github.com/tbogdala/fizzle"
+	component "github.com/tbogdala/fizzle/component"
+	scene "github.com/tbogdala/fizzle/scene"
+)
+
+// ComponentEntity is an interface for entities created from a component.
+type ComponentEntity interface {
+	GetComponentName() string
+}
+
+// RenderableEntity is an interface for entities that have a renderable to draw.
+type RenderableEntity interface {
+	GetRenderable() *fizzle.Renderable
+}
+
+// RenderableSetter is an interface for entities whose renderable can be
+// replaced, such as when the component it was created from changes.
+type RenderableSetter interface {
+	SetRenderable(r *fizzle.Renderable)
+}
+
+// ColliderRebuilder is an interface for entities whose colliders can be
+// created again from a changed component.
+type ColliderRebuilder interface {
+	RebuildColliders(c *component.Component)
+}
+
+// TranslucentEntity is an interface for entities that need to be drawn after
+// all of the opaque entities so that they blend with them.
+type TranslucentEntity interface {
+	IsTranslucent() bool
+}
+
+// VisibleEntity is a scene entity that can be rendered to screen.
+type VisibleEntity struct {
+	*scene.BasicEntity
+
+	// Renderable is the model to draw for the entity if one should be
+	// drawn -- it is valid to have a nil Renderable here.
+	Renderable *fizzle.Renderable
+
+	// ComponentName is the name of the component the entity was created
+	// from, if any.
+	ComponentName string
+
+	// CollisionLayer is the layer of the colliders that have no layer in
+	// their tags. It's layerHazard if it isn't set.
+	CollisionLayer CollisionLayer
+
+	// localColliders are the coarse colliders as they were created from the
+	// component, before the orientation of the entity is applied to them.
+	localColliders []glider.Collider
+
+	// colliderLayers are the collision layers of each coarse collider.
+	colliderLayers []ColliderLayers
+
+	// colliderTags are the tags of each coarse collider from the component.
+	colliderTags [][]string
+
+	// IgnoreParentOrientation makes the entity follow only the location of
+	// its parent, without turning with it or swinging around it, such as
+	// for the player's rig that shouldn't roll with the ship.
+	IgnoreParentOrientation bool
+
+	// parent is the entity this one is attached to, if any, and local is
+	// the transform relative to it; without a parent local is the transform
+	// in the world. children are the entities attached to this one.
+	parent   *VisibleEntity
+	local    Transform
+	children []SpawnableEntity
+}
+
+// Transform is where an entity is placed and how it's turned.
+type Transform struct {
+	Location mgl.Vec3
+
+	// Orientation is the rotation of the entity; the zero value is no rotation.
+	Orientation mgl.Quat
+}
+
+// NewVisibleEntity returns a new visible entity object.
+func NewVisibleEntity() *VisibleEntity {
+	ve := new(VisibleEntity)
+	ve.BasicEntity = scene.NewBasicEntity()
+	return ve
+}
+
+// GetVisibleEntity returns the entity so that the types embedding it can be
+// set up as one.
+func (e *VisibleEntity) GetVisibleEntity() *VisibleEntity {
+	return e
+}
+
+// GetRenderable returns the renderable for the entity.
+func (e *VisibleEntity) GetRenderable() *fizzle.Renderable {
+	return e.Renderable
+}
+
+// SetRenderable sets the renderable for the entity.
+func (e *VisibleEntity) SetRenderable(r *fizzle.Renderable) {
+	e.Renderable = r
+}
+
+// CreateCollidersFromComponent adds the coarse colliders of the component to
+// the entity, oriented and placed where the entity is.
+func (e *VisibleEntity) CreateCollidersFromComponent(c *component.Component) {
+	first := len(e.CoarseColliders)
+	e.BasicEntity.CreateCollidersFromComponent(c)
+
+	fallback := e.CollisionLayer
+	if fallback == 0 {
+		fallback = layerHazard
+	}
+	for i, collider := range e.CoarseColliders[first:] {
+		e.localColliders = append(e.localColliders, collider.Clone())
+
+		// unknown layers are reported by the asset validation so they are
+		// simply left out here.
+		var tags []string
+		if len(c.Collisions) == len(e.CoarseColliders)-first {
+			tags = c.Collisions[i].Tags
+		}
+		layers, _ := parseColliderLayers(tags, fallback)
+		e.colliderLayers = append(e.colliderLayers, layers)
+		e.colliderTags = append(e.colliderTags, tags)
+	}
+	e.orientColliders()
+}
+
+// GetColliderLayers returns the collision layers of each coarse collider.
+func (e *VisibleEntity) GetColliderLayers() []ColliderLayers {
+	return e.colliderLayers
+}
+
+// GetColliderTags returns the tags of each coarse collider.
+func (e *VisibleEntity) GetColliderTags() [][]string {
+	return e.colliderTags
+}
+
+// RebuildColliders replaces the coarse colliders of the entity with new
+// ones created from the component.
+func (e *VisibleEntity) RebuildColliders(c *component.Component) {
+	e.CoarseColliders = nil
+	e.localColliders = nil
+	e.colliderLayers = nil
+	e.colliderTags = nil
+	e.CreateCollidersFromComponent(c)
+}
+
+// GetComponentName returns the name of the component the entity was created from.
+func (e *VisibleEntity) GetComponentName() string {
+	return e.ComponentName
+}
+
+// SetLocation is a helper function to set the location of the entity as well
+// as any renderable. The location is in the world even if the entity is
+// attached to another, and the entities attached to it move along.
+func (e *VisibleEntity) SetLocation(pos mgl.Vec3) {
+	if e.parent == nil {
+		e.local.Location = pos
+	} else {
+		offset := pos.Sub(e.parent.GetLocation())
+		if !e.IgnoreParentOrientation {
+			offset = orientationOrIdent(e.parent.GetOrientation()).Inverse().Rotate(offset)
+		}
+		e.local.Location = offset
+	}
+	e.placeAt(pos)
+	e.resolveChildren()
+}
+
+// SetOrientation is a helper function to set the orientation of the entity as
+// well as any renderable. The orientation is in the world even if the entity
+// is attached to another, and the entities attached to it turn along.
+func (e *VisibleEntity) SetOrientation(q mgl.Quat) {
+	if e.parent == nil || e.IgnoreParentOrientation {
+		e.local.Orientation = q
+	} else {
+		e.local.Orientation = orientationOrIdent(e.parent.GetOrientation()).Inverse().Mul(q)
+	}
+	e.turnTo(q)
+	e.resolveChildren()
+}
+
+// placeAt moves the entity, its renderable and its colliders to the location.
+func (e *VisibleEntity) placeAt(pos mgl.Vec3) {
+	e.BasicEntity.SetLocation(pos)
+	if e.Renderable != nil {
+		e.Renderable.Location = pos
+	}
+	for _, c := range e.CoarseColliders {
+		c.SetOffset(&pos)
+	}
+}
+
+// turnTo turns the entity, its renderable and its colliders to the orientation.
+func (e *VisibleEntity) turnTo(q mgl.Quat) {
+	e.BasicEntity.SetOrientation(q)
+	if e.Renderable != nil {
+		e.Renderable.LocalRotation = q
+	}
+	e.orientColliders()
+}
+
+// orientationOrIdent returns the orientation, treating the zero quaternion
+// as no rotation.
+func orientationOrIdent(q mgl.Quat) mgl.Quat {
+	if q == (mgl.Quat{}) {
+		return mgl.QuatIdent()
+	}
+	return q
+}
+
+// Attach makes the child move and turn with the entity, placed at the local
+// transform relative to it. The child is detached from the entity it was
+// attached to first, if any. An entity can't be attached to one of its own
+// children.
+func (e *VisibleEntity) Attach(child SpawnableEntity, local Transform) error {
+	ve := child.GetVisibleEntity()
+	for ancestor := e; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == ve {
+			return fmt.Errorf("can't attach %s to itself or its children", ve.GetName())
+		}
+	}
+	ve.Detach()
+	ve.parent = e
+	e.children = append(e.children, child)
+	ve.SetLocalTransform(local)
+	return nil
+}
+
+// Detach removes the entity from the entity it's attached to, leaving it
+// where it is in the world.
+func (e *VisibleEntity) Detach() {
+	if e.parent == nil {
+		return
+	}
+	siblings := e.parent.children
+	for i, sibling := range siblings {
+		if sibling.GetVisibleEntity() == e {
+			e.parent.children = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	e.parent = nil
+	e.local = Transform{Location: e.GetLocation(), Orientation: e.GetOrientation()}
+}
+
+// GetParent returns the entity this one is attached to or nil if it isn't.
+func (e *VisibleEntity) GetParent() *VisibleEntity {
+	return e.parent
+}
+
+// GetChildren returns the entities attached to this one.
+func (e *VisibleEntity) GetChildren() []SpawnableEntity {
+	return e.children
+}
+
+// GetLocalTransform returns the transform of the entity relative to the
+// entity it's attached to, or in the world if it isn't attached.
+func (e *VisibleEntity) GetLocalTransform() Transform {
+	return e.local
+}
+
+// SetLocalTransform places the entity relative to the entity it's attached
+// to, or in the world if it isn't attached, and moves its children along.
+func (e *VisibleEntity) SetLocalTransform(t Transform) {
+	e.local = t
+	e.resolveTransform()
+}
+
+// SetLocalLocation moves the entity relative to the entity it's attached
+// to, or in the world if it isn't attached, keeping its local orientation.
+func (e *VisibleEntity) SetLocalLocation(pos mgl.Vec3) {
+	e.local.Location = pos
+	e.resolveTransform()
+}
+
+// resolveTransform places the entity in the world from its local transform
+// and the transform of its parent, and then does the same for its children.
+func (e *VisibleEntity) resolveTransform() {
+	pos, q := e.local.Location, e.local.Orientation
+	if e.parent != nil {
+		parentPos := e.parent.GetLocation()
+		if e.IgnoreParentOrientation {
+			pos = parentPos.Add(pos)
+		} else {
+			parentQ := orientationOrIdent(e.parent.GetOrientation())
+			pos = parentPos.Add(parentQ.Rotate(pos))
+			q = parentQ.Mul(orientationOrIdent(q))
+		}
+	}
+
+	e.placeAt(pos)
+	if q != (mgl.Quat{}) {
+		e.turnTo(q)
+	}
+	e.resolveChildren()
+}
+
+// resolveChildren places the children of the entity relative to where it is now.
+func (e *VisibleEntity) resolveChildren() {
+	for _, child := range e.children {
+		child.GetVisibleEntity().resolveTransform()
+	}
+}
+
+// orientColliders rotates the coarse colliders by the orientation of the
+// entity and moves them to its location. The colliders stay axis aligned so
+// a box becomes the box around its rotated corners: it grows while the
+// entity is at an angle and is tight again at right angles, so a ship rolled
+// onto its side is as narrow as it is tall.
+func (e *VisibleEntity) orientColliders() {
+	q := e.GetOrientation()
+	if q == (mgl.Quat{}) {
+		// treat a zero quaternion as no rotation so entities that were
+		// never oriented keep their colliders.
+		q = mgl.QuatIdent()
+	}
+	pos := e.GetLocation()
+	for i, local := range e.localColliders {
+		if i >= len(e.CoarseColliders) {
+			break
+		}
+		switch shape := local.(type) {
+		case *glider.AABBox:
+			if box, okay := e.CoarseColliders[i].(*glider.AABBox); okay {
+				box.Min, box.Max = rotateBounds(shape.Min, shape.Max, q)
+			}
+		case *glider.Sphere:
+			if sphere, okay := e.CoarseColliders[i].(*glider.Sphere); okay {
+				sphere.Center = q.Rotate(shape.Center)
+			}
+		}
+		e.CoarseColliders[i].SetOffset(&pos)
+	}
+}
+
+// rotateBounds returns the axis aligned bounds of the box from min to max
+// after it's rotated about the origin.
+func rotateBounds(min, max mgl.Vec3, q mgl.Quat) (mgl.Vec3, mgl.Vec3) {
+	var newMin, newMax mgl.Vec3
+	for i := 0; i < 8; i++ {
+		corner := min
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				corner[axis] = max[axis]
+			}
+		}
+		corner = q.Rotate(corner)
+		if i == 0 {
+			newMin, newMax = corner, corner
+			continue
+		}
+		for axis := 0; axis < 3; axis++ {
+			newMin[axis] = float32(math.Min(float64(newMin[axis]), float64(corner[axis])))
+			newMax[axis] = float32(math.Max(float64(newMax[axis]), float64(corner[axis])))
+		}
+	}
+	return newMin, newMax
+}
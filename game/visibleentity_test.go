@@ -0,0 +1,151 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package game
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	component "github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/glider"
+)
+
+// testShipComponent is a ship twice as wide as the gap in the test walls
+// and thin enough to fit through it on its side.
+var testShipComponent = &component.Component{
+	Name: "TestShip",
+	Collisions: []*component.CollisionRef{
+		{
+			Type: component.ColliderTypeAABB,
+			Min:  mgl.Vec3{-1.0, -0.1, -0.5},
+			Max:  mgl.Vec3{1.0, 0.1, 0.5},
+		},
+		{
+			Type:   component.ColliderTypeSphere,
+			Offset: mgl.Vec3{0.0, 0.25, 0.0},
+			Radius: 0.05,
+		},
+	},
+}
+
+// newTestGap returns the walls on either side of a gap that's 1.7 wide
+// centered on x=0 around the location.
+func newTestGap(location mgl.Vec3) []glider.Collider {
+	left := &glider.AABBox{Min: mgl.Vec3{-5.0, -5.0, -1.0}, Max: mgl.Vec3{-0.85, 5.0, 1.0}}
+	right := &glider.AABBox{Min: mgl.Vec3{0.85, -5.0, -1.0}, Max: mgl.Vec3{5.0, 5.0, 1.0}}
+	left.SetOffset(&location)
+	right.SetOffset(&location)
+	return []glider.Collider{left, right}
+}
+
+// hitsAny returns true if the box collides with any of the walls.
+func hitsAny(box glider.Collider, walls []glider.Collider) bool {
+	for _, wall := range walls {
+		if glider.Collide(box, wall) != glider.NoIntersect {
+			return true
+		}
+	}
+	return false
+}
+
+// worldBounds returns the bounds of the box in the world.
+func worldBounds(box *glider.AABBox) (mgl.Vec3, mgl.Vec3) {
+	return box.Min.Add(box.Offset), box.Max.Add(box.Offset)
+}
+
+func vec3ApproxEqual(a, b mgl.Vec3) bool {
+	return a.ApproxEqualThreshold(b, 1e-4)
+}
+
+func TestRolledShipClearsGap(t *testing.T) {
+	location := mgl.Vec3{0.0, 5.0, 20.0}
+	walls := newTestGap(location)
+
+	tests := []struct {
+		degrees    float32
+		halfWidth  float32
+		halfHeight float32
+	}{
+		// the box around the rolled corners is (1.0+0.1)*cos(45°) wide either way
+		{45.0, 0.7778, 0.7778},
+		{90.0, 0.1, 1.0},
+	}
+
+	for _, test := range tests {
+		ship := NewVisibleEntity()
+		ship.CreateCollidersFromComponent(testShipComponent)
+		ship.SetLocation(location)
+		box := ship.CoarseColliders[0].(*glider.AABBox)
+		if !hitsAny(box, walls) {
+			t.Fatalf("the level ship should hit the walls of the gap")
+		}
+
+		ship.SetOrientation(mgl.QuatRotate(mgl.DegToRad(test.degrees), mgl.Vec3{0.0, 0.0, 1.0}))
+		min, max := worldBounds(box)
+		if !vec3ApproxEqual(min, location.Sub(mgl.Vec3{test.halfWidth, test.halfHeight, 0.5})) ||
+			!vec3ApproxEqual(max, location.Add(mgl.Vec3{test.halfWidth, test.halfHeight, 0.5})) {
+			t.Errorf("rolled %.0f°: the box is %v to %v", test.degrees, min, max)
+		}
+		if hitsAny(box, walls) {
+			t.Errorf("rolled %.0f°: the ship should clear the gap", test.degrees)
+		}
+
+		// levelling out makes it as wide as it was
+		ship.SetOrientation(mgl.QuatIdent())
+		if !hitsAny(box, walls) {
+			t.Errorf("rolled back from %.0f°: the level ship should hit the walls again", test.degrees)
+		}
+	}
+}
+
+func TestSetLocationKeepsOrientation(t *testing.T) {
+	ship := NewVisibleEntity()
+	ship.CreateCollidersFromComponent(testShipComponent)
+	roll := mgl.QuatRotate(mgl.DegToRad(90.0), mgl.Vec3{0.0, 0.0, 1.0})
+
+	// turning then moving and moving then turning end up the same
+	ship.SetOrientation(roll)
+	for _, location := range []mgl.Vec3{{3.0, 5.0, 40.0}, {-2.0, 1.0, 10.0}} {
+		ship.SetLocation(location)
+
+		box := ship.CoarseColliders[0].(*glider.AABBox)
+		min, max := worldBounds(box)
+		if !vec3ApproxEqual(min, location.Add(mgl.Vec3{-0.1, -1.0, -0.5})) ||
+			!vec3ApproxEqual(max, location.Add(mgl.Vec3{0.1, 1.0, 0.5})) {
+			t.Errorf("moved to %v: the box is %v to %v", location, min, max)
+		}
+		if hitsAny(box, newTestGap(location)) {
+			t.Errorf("moved to %v: the rolled ship should clear the gap there", location)
+		}
+
+		sphere := ship.CoarseColliders[1].(*glider.Sphere)
+		center := sphere.Center.Add(sphere.Offset)
+		if !vec3ApproxEqual(center, location.Add(mgl.Vec3{-0.25, 0.0, 0.0})) {
+			t.Errorf("moved to %v: the sphere is at %v", location, center)
+		}
+		if ship.GetOrientation() != roll {
+			t.Errorf("moved to %v: the orientation changed to %v", location, ship.GetOrientation())
+		}
+	}
+
+	moved := NewVisibleEntity()
+	moved.CreateCollidersFromComponent(testShipComponent)
+	moved.SetLocation(mgl.Vec3{-2.0, 1.0, 10.0})
+	moved.SetOrientation(roll)
+	for i := range moved.CoarseColliders {
+		switch shape := moved.CoarseColliders[i].(type) {
+		case *glider.AABBox:
+			min, max := worldBounds(shape)
+			otherMin, otherMax := worldBounds(ship.CoarseColliders[i].(*glider.AABBox))
+			if !vec3ApproxEqual(min, otherMin) || !vec3ApproxEqual(max, otherMax) {
+				t.Errorf("the box moved then turned is %v to %v, turned then moved %v to %v", min, max, otherMin, otherMax)
+			}
+		case *glider.Sphere:
+			other := ship.CoarseColliders[i].(*glider.Sphere)
+			if !vec3ApproxEqual(shape.Center.Add(shape.Offset), other.Center.Add(other.Offset)) {
+				t.Errorf("the sphere moved then turned doesn't match the one turned then moved")
+			}
+		}
+	}
+}
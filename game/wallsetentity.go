@@ -1,7 +1,7 @@
 // Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
 // See the LICENSE file for more details.
 
-package main
+package game
 
 import (
 	mgl "github.com/go-gl/mathgl/mgl32"
@@ -17,12 +17,13 @@ type WallSetEntity struct {
 func NewWallSetEntity() *WallSetEntity {
 	wse := new(WallSetEntity)
 	wse.VisibleEntity = NewVisibleEntity()
+	wse.CollisionLayer = layerWall
 	return wse
 }
 
 // ScrollPastPlayer should move the entity with relation to the inverse
 // of the player speed, adjusted for frame delta.
-func (wse *WallSetEntity) ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32) {
+func (wse *WallSetEntity) ScrollPastPlayer(backwardSpeed mgl.Vec3, frameDelta float32, gameTime float64) {
 	// move everything else back the current speed of the ship
 	loc := wse.GetLocation().Add(backwardSpeed)
 	wse.SetLocation(loc)
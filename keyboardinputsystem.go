@@ -5,10 +5,11 @@ package main
 
 import (
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
-	mgl "github.com/go-gl/mathgl/mgl32"
 
 	input "github.com/tbogdala/fizzle/input/glfwinput"
 	"github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 const (
@@ -16,21 +17,17 @@ const (
 	keyboardInputSystemName     = "KeyboardInputSystem"
 )
 
-var (
-	// scales the roll and pitch movement for keypresses
-	kbRollPitchSpeedF = float32(1.5)
-)
-
 // KeyboardInputSystem implements the System interface and handles the
 // player input via keyboard.
 type KeyboardInputSystem struct {
 	kbModel    *input.KeyboardModel
 	mainWindow *glfw.Window
 
-	frameDelta float32
+	// controller is the ship controller that receives the keyboard intents.
+	controller *game.ShipController
 
-	// playerShipEntity is the cached reference to the player ship pawn.
-	playerShipEntity *ShipEntity
+	// intent is built up by the key handlers each frame.
+	intent game.ShipIntent
 }
 
 // NewKeyboardInputSystem creates a new KeyboardInputSystem object
@@ -40,8 +37,9 @@ func NewKeyboardInputSystem() *KeyboardInputSystem {
 }
 
 // Initialize sets up the input models for the scene.
-func (s *KeyboardInputSystem) Initialize(w *glfw.Window) {
+func (s *KeyboardInputSystem) Initialize(w *glfw.Window, controller *game.ShipController) {
 	s.mainWindow = w
+	s.controller = controller
 
 	// set the callback functions for key input
 	s.kbModel = input.NewKeyboardModel(s.mainWindow)
@@ -49,6 +47,7 @@ func (s *KeyboardInputSystem) Initialize(w *glfw.Window) {
 	s.kbModel.Bind(glfw.KeyD, s.handleRollRight)
 	s.kbModel.Bind(glfw.KeyW, s.handlePitchUp)
 	s.kbModel.Bind(glfw.KeyS, s.handlePitchDown)
+	s.kbModel.Bind(glfw.KeySpace, s.handleBoost)
 	s.kbModel.SetupCallbacks()
 
 }
@@ -56,49 +55,27 @@ func (s *KeyboardInputSystem) Initialize(w *glfw.Window) {
 // Update should get called to run updates for the system every frame
 // by the owning Manager object.
 func (s *KeyboardInputSystem) Update(frameDelta float32) {
-	// cache this in the system object so the keyboard handlers can reference it
-	s.frameDelta = frameDelta
-
 	// advise GLFW to poll for input. without this the window appears to hang.
 	glfw.PollEvents()
 
-	// if the game state is in the player died state, do not process input
-	if gameScene.gameState == gameStatePlayerDied {
+	// if the game state is not in the playing state, do not process input
+	if gameScene.State() != game.GameStatePlaying {
 		return
 	}
 
-	// handle any keyboard input
+	// handle any keyboard input and send the intent to the ship controller
+	s.intent = game.ShipIntent{}
 	s.kbModel.CheckKeyPresses()
-
-	// modify ship rotation based on input state
-	qRoll := mgl.QuatRotate(s.playerShipEntity.currentShipRoll, mgl.Vec3{0.0, 0.0, 1.0})
-	qPitch := mgl.QuatRotate(s.playerShipEntity.currentShipPitch, mgl.Vec3{1.0, 0.0, 0.0})
-	s.playerShipEntity.SetOrientation(qRoll.Mul(qPitch))
-
-	// HACK: move the ship around
-	rollRatio := s.playerShipEntity.currentShipRoll / maxRollRads
-	pitchRatio := s.playerShipEntity.currentShipPitch / maxPitchRads
-	shipLoc := s.playerShipEntity.GetLocation()
-	shipLoc[0] -= shipMoveSpeed * rollRatio * frameDelta
-	shipLoc[1] -= shipMoveSpeed * pitchRatio * frameDelta
-	s.playerShipEntity.SetLocation(shipLoc)
+	s.controller.AddIntent(s.intent)
 }
 
 // OnAddEntity should get called by the scene Manager each time a new entity
 // has been added to the scene.
-func (s *KeyboardInputSystem) OnAddEntity(newEntity scene.Entity) {
-	if newEntity.GetName() == playerShipEntityName {
-		s.playerShipEntity = newEntity.(*ShipEntity)
-	}
-}
+func (s *KeyboardInputSystem) OnAddEntity(newEntity scene.Entity) {}
 
 // OnRemoveEntity should get called by the scene Manager each time an entity
 // has been removed from the scene.
-func (s *KeyboardInputSystem) OnRemoveEntity(oldEntity scene.Entity) {
-	if oldEntity.GetName() == playerShipEntityName {
-		s.playerShipEntity = nil
-	}
-}
+func (s *KeyboardInputSystem) OnRemoveEntity(oldEntity scene.Entity) {}
 
 // GetRequestedPriority returns the requested priority level for the System
 // which may be of significance to a Manager if they want to order Update() calls.
@@ -128,22 +105,22 @@ func (s *KeyboardInputSystem) handlePitchUp() {
 	s.handlePitchUpV(1.0)
 }
 
+func (s *KeyboardInputSystem) handleBoost() {
+	s.intent.Boost = 1.0
+}
+
 func (s *KeyboardInputSystem) handleRollLeftV(v float32) {
-	s.playerShipEntity.currentShipRoll += -v * maxRollRads * s.frameDelta * kbRollPitchSpeedF
-	s.playerShipEntity.currentShipRoll = mgl.Clamp(s.playerShipEntity.currentShipRoll, -maxRollRads, maxRollRads)
+	s.intent.Roll -= v
 }
 
 func (s *KeyboardInputSystem) handleRollRightV(v float32) {
-	s.playerShipEntity.currentShipRoll += v * maxRollRads * s.frameDelta * kbRollPitchSpeedF
-	s.playerShipEntity.currentShipRoll = mgl.Clamp(s.playerShipEntity.currentShipRoll, -maxRollRads, maxRollRads)
+	s.intent.Roll += v
 }
 
 func (s *KeyboardInputSystem) handlePitchDownV(v float32) {
-	s.playerShipEntity.currentShipPitch += v * maxPitchRads * s.frameDelta * kbRollPitchSpeedF
-	s.playerShipEntity.currentShipPitch = mgl.Clamp(s.playerShipEntity.currentShipPitch, -maxPitchRads, maxPitchRads)
+	s.intent.Pitch += v
 }
 
 func (s *KeyboardInputSystem) handlePitchUpV(v float32) {
-	s.playerShipEntity.currentShipPitch += -v * maxPitchRads * s.frameDelta * kbRollPitchSpeedF
-	s.playerShipEntity.currentShipPitch = mgl.Clamp(s.playerShipEntity.currentShipPitch, -maxPitchRads, maxPitchRads)
+	s.intent.Pitch -= v
 }
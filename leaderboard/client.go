@@ -0,0 +1,290 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tbogdala/infinigrid/atomicfile"
+)
+
+// ErrQueued is returned by Client.Submit when the server couldn't be reached
+// and the submission was queued to be sent by a later Flush.
+var ErrQueued = errors.New("leaderboard unavailable; submission queued")
+
+// QueueSaveError is returned by Client.Submit when the submission was queued
+// but the queue couldn't be saved to its file, so the submission is lost if
+// the game exits before a Flush sends it.
+type QueueSaveError struct {
+	Err error
+}
+
+func (e *QueueSaveError) Error() string {
+	return fmt.Sprintf("%v but the queue could not be saved: %v", ErrQueued, e.Err)
+}
+
+// IsQueued returns true if the error returned by Client.Submit means the
+// submission was queued, whether or not the queue could be saved.
+func IsQueued(err error) bool {
+	if err == ErrQueued {
+		return true
+	}
+	_, okay := err.(*QueueSaveError)
+	return okay
+}
+
+// Client submits runs to a leaderboard server and fetches the top lists.
+// Submissions that can't be delivered are kept in a queue, optionally
+// persisted to a file, and retried with Flush.
+type Client struct {
+	// BaseURL is the address of the server, such as http://192.168.1.10:8080.
+	BaseURL string
+
+	// HTTPClient is used for all requests.
+	HTTPClient *http.Client
+
+	// MaxAttempts is how many times a request is tried before giving up.
+	MaxAttempts int
+
+	// RetryDelay is the delay before the first retry; it doubles each attempt.
+	RetryDelay time.Duration
+
+	queueFile string
+	lock      sync.Mutex
+	queue     []*Submission
+}
+
+// statusError is returned for requests the server answered with an error.
+type statusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("leaderboard server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// isTransient returns true if the request that failed with err is worth retrying.
+func isTransient(err error) bool {
+	se, okay := err.(*statusError)
+	if !okay {
+		// network errors are always worth trying again
+		return true
+	}
+	return se.StatusCode >= 500 || se.StatusCode == http.StatusTooManyRequests
+}
+
+// NewClient creates a new leaderboard client for the server at baseURL. If
+// queueFile is not empty, undelivered submissions are persisted to it.
+func NewClient(baseURL string, queueFile string) (*Client, error) {
+	c := new(Client)
+	c.BaseURL = strings.TrimRight(baseURL, "/")
+	c.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	c.MaxAttempts = 3
+	c.RetryDelay = 500 * time.Millisecond
+	c.queueFile = queueFile
+
+	if queueFile != "" {
+		jsonBytes, err := ioutil.ReadFile(queueFile)
+		if err != nil && !os.IsNotExist(err) {
+			return c, fmt.Errorf("failed to read the submission queue: %v", err)
+		} else if err == nil {
+			err = json.Unmarshal(jsonBytes, &c.queue)
+			if err != nil {
+				return c, fmt.Errorf("failed to parse the submission queue: %v", err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Submit sends the run to the server. If the server can't be reached the
+// submission is queued and ErrQueued is returned, or a *QueueSaveError if
+// the queue couldn't be saved; IsQueued is true for both.
+func (c *Client) Submit(sub *Submission) (Entry, error) {
+	var entry Entry
+	err := c.withRetries(func() error {
+		return c.doJSON(http.MethodPost, scoresPath, sub, &entry)
+	})
+	if err != nil && isTransient(err) {
+		c.lock.Lock()
+		c.queue = append(c.queue, sub)
+		saveErr := c.saveQueue()
+		c.lock.Unlock()
+		if saveErr != nil {
+			return entry, &QueueSaveError{Err: saveErr}
+		}
+		return entry, ErrQueued
+	}
+	return entry, err
+}
+
+// Pending returns the number of queued submissions.
+func (c *Client) Pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.queue)
+}
+
+// Flush tries to send the queued submissions in order. Submissions the server
+// rejects outright are dropped. It returns the number of submissions sent.
+func (c *Client) Flush() (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sent := 0
+	var lastErr error
+	remaining := []*Submission{}
+	for i, sub := range c.queue {
+		err := c.doJSON(http.MethodPost, scoresPath, sub, nil)
+		if err == nil {
+			sent++
+			continue
+		}
+		if isTransient(err) {
+			// the server is still unavailable so keep the rest for later
+			remaining = append(remaining, c.queue[i:]...)
+			lastErr = err
+			break
+		}
+		lastErr = err
+	}
+	c.queue = remaining
+
+	err := c.saveQueue()
+	if err != nil {
+		return sent, err
+	}
+	return sent, lastErr
+}
+
+// Top fetches the best entries for the game mode.
+func (c *Client) Top(mode string, limit int) ([]Entry, error) {
+	query := url.Values{}
+	query.Set("mode", mode)
+	query.Set("limit", strconv.Itoa(limit))
+
+	var entries []Entry
+	err := c.withRetries(func() error {
+		return c.doJSON(http.MethodGet, scoresPath+"?"+query.Encode(), nil, &entries)
+	})
+	return entries, err
+}
+
+// FetchReplay downloads the replay blob for the entry id.
+func (c *Client) FetchReplay(id string) ([]byte, error) {
+	var blob []byte
+	err := c.withRetries(func() error {
+		resp, err := c.HTTPClient.Get(c.BaseURL + replaysPath + url.PathEscape(id))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return readStatusError(resp)
+		}
+		blob, err = ioutil.ReadAll(io.LimitReader(resp.Body, MaxReplaySize))
+		return err
+	})
+	return blob, err
+}
+
+// withRetries calls f until it succeeds, fails with a permanent error or
+// runs out of attempts.
+func (c *Client) withRetries(f func() error) error {
+	delay := c.RetryDelay
+	var err error
+	for attempt := 0; attempt < c.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		err = f()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// doJSON sends the request body as JSON and decodes the JSON response into result.
+func (c *Client) doJSON(method string, path string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBytes, err := json.Marshal(body)
+		if err != nil {
+			return &statusError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+		}
+		reqBody = bytes.NewReader(jsonBytes)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return &statusError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return readStatusError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// readStatusError builds a statusError from a failed response.
+func readStatusError(resp *http.Response) error {
+	var errResp errorResponse
+	err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&errResp)
+	if err != nil || errResp.Error == "" {
+		errResp.Error = http.StatusText(resp.StatusCode)
+	}
+	return &statusError{StatusCode: resp.StatusCode, Message: errResp.Error}
+}
+
+// saveQueue persists the queue; the lock must be held by the caller.
+func (c *Client) saveQueue() error {
+	if c.queueFile == "" {
+		return nil
+	}
+	if len(c.queue) == 0 {
+		err := os.Remove(c.queueFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the submission queue: %v", err)
+		}
+		return nil
+	}
+
+	jsonBytes, err := json.Marshal(c.queue)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the submission queue: %v", err)
+	}
+	err = atomicfile.WriteFile(c.queueFile, jsonBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write the submission queue: %v", err)
+	}
+	return nil
+}
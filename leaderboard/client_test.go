@@ -0,0 +1,169 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchableHandler answers 503 Service Unavailable while it's down and
+// passes the requests to the server otherwise.
+type switchableHandler struct {
+	server http.Handler
+	lock   sync.Mutex
+	down   bool
+}
+
+func (h *switchableHandler) setDown(down bool) {
+	h.lock.Lock()
+	h.down = down
+	h.lock.Unlock()
+}
+
+func (h *switchableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.lock.Lock()
+	down := h.down
+	h.lock.Unlock()
+	if down {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+		return
+	}
+	h.server.ServeHTTP(w, r)
+}
+
+func TestOfflineQueueAndFlush(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+	store, err := OpenFileStore(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	handler := &switchableHandler{server: NewServer(store), down: true}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	queueFile := filepath.Join(dir, "queue.json")
+	client, err := NewClient(ts.URL, queueFile)
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	client.RetryDelay = time.Millisecond
+
+	// while the server is down the runs are queued and persisted
+	for _, name := range []string{"first", "second"} {
+		_, err := client.Submit(testSubmission(name, "normal", 100))
+		if err != ErrQueued {
+			t.Fatalf("expected %s to be queued, got %v", name, err)
+		}
+	}
+	if client.Pending() != 2 {
+		t.Fatalf("expected 2 queued runs, got %d", client.Pending())
+	}
+	sent, err := client.Flush()
+	if sent != 0 || err == nil {
+		t.Errorf("flushing while the server is down sent %d runs with error %v", sent, err)
+	}
+
+	// a client started later picks up the queue from the file
+	restarted, err := NewClient(ts.URL, queueFile)
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	if restarted.Pending() != 2 {
+		t.Fatalf("expected the queue file to hold 2 runs, got %d", restarted.Pending())
+	}
+
+	handler.setDown(false)
+	sent, err = restarted.Flush()
+	if err != nil || sent != 2 {
+		t.Fatalf("expected 2 runs to be sent, sent %d with error %v", sent, err)
+	}
+	if restarted.Pending() != 0 {
+		t.Errorf("expected the queue to be empty, got %d", restarted.Pending())
+	}
+	if _, err := os.Stat(queueFile); !os.IsNotExist(err) {
+		t.Errorf("expected the empty queue file to be removed, got %v", err)
+	}
+
+	top, err := restarted.Top("normal", 10)
+	if err != nil {
+		t.Fatalf("failed to fetch the top list: %v", err)
+	}
+	if len(top) != 2 {
+		t.Errorf("expected the flushed runs on the leaderboard, got %+v", top)
+	}
+}
+
+func TestQueueSaveFails(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+	ts := httptest.NewServer(&switchableHandler{down: true})
+	defer ts.Close()
+
+	// the queue can't be written into a directory that doesn't exist
+	client, err := NewClient(ts.URL, filepath.Join(dir, "missing", "queue.json"))
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	client.RetryDelay = time.Millisecond
+
+	_, err = client.Submit(testSubmission("unsaved", "normal", 100))
+	if _, okay := err.(*QueueSaveError); !okay {
+		t.Fatalf("expected a *QueueSaveError, got %v", err)
+	}
+	if !IsQueued(err) {
+		t.Errorf("the run that couldn't be saved isn't reported as queued")
+	}
+	if client.Pending() != 1 {
+		t.Errorf("expected the run to still be queued in memory, got %d", client.Pending())
+	}
+	if IsQueued(&RejectedError{Report: "impossible score"}) {
+		t.Errorf("a rejected run is reported as queued")
+	}
+}
+
+func TestFlushDropsRejectedRuns(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	server := NewServer(store)
+	server.Verifier = verifierFunc(func(sub *Submission) error {
+		if sub.Name == "cheater" {
+			return &RejectedError{Report: "impossible score"}
+		}
+		return nil
+	})
+	handler := &switchableHandler{server: server, down: true}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, "")
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	client.RetryDelay = time.Millisecond
+	client.Submit(testSubmission("cheater", "normal", 100000))
+	client.Submit(testSubmission("honest", "normal", 100))
+
+	handler.setDown(false)
+	sent, err := client.Flush()
+	if sent != 1 {
+		t.Errorf("expected the honest run to be sent, sent %d", sent)
+	}
+	if err == nil {
+		t.Errorf("expected the rejection to be reported")
+	}
+	if client.Pending() != 0 {
+		t.Errorf("the rejected run is still queued")
+	}
+}
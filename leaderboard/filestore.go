@@ -0,0 +1,151 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tbogdala/infinigrid/atomicfile"
+)
+
+const (
+	entriesFileName = "entries.json"
+	replaysDirName  = "replays"
+	replayFileExt   = ".igr"
+)
+
+// FileStore keeps the leaderboard entries in a JSON file and the replay
+// blobs as individual files inside a directory.
+type FileStore struct {
+	dir     string
+	lock    sync.Mutex
+	entries []Entry
+}
+
+// OpenFileStore opens the file store in dir, creating it if necessary.
+func OpenFileStore(dir string) (*FileStore, error) {
+	fs := new(FileStore)
+	fs.dir = dir
+
+	err := os.MkdirAll(filepath.Join(dir, replaysDirName), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the store directory: %v", err)
+	}
+
+	jsonBytes, err := ioutil.ReadFile(filepath.Join(dir, entriesFileName))
+	if os.IsNotExist(err) {
+		return fs, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the leaderboard entries: %v", err)
+	}
+
+	err = json.Unmarshal(jsonBytes, &fs.entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the leaderboard entries: %v", err)
+	}
+	return fs, nil
+}
+
+// newEntryID returns a random identifier for an entry.
+func newEntryID() (string, error) {
+	idBytes := make([]byte, 8)
+	_, err := rand.Read(idBytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// Add stores the submission and returns the new entry.
+func (fs *FileStore) Add(sub *Submission) (Entry, error) {
+	id, err := newEntryID()
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to create an entry id: %v", err)
+	}
+
+	entry := Entry{
+		ID:        id,
+		Name:      sub.Name,
+		Mode:      sub.Mode,
+		Seed:      sub.Seed,
+		Score:     sub.Score,
+		Distance:  sub.Distance,
+		Duration:  sub.Duration,
+		Date:      sub.Date,
+		Submitted: time.Now().UTC(),
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	// the replay is written first so an entry never references a missing replay
+	err = atomicfile.WriteFile(fs.replayPath(id), sub.Replay)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to write the replay: %v", err)
+	}
+
+	entries := append(fs.entries, entry)
+	jsonBytes, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to serialize the entries: %v", err)
+	}
+	err = atomicfile.WriteFile(filepath.Join(fs.dir, entriesFileName), jsonBytes)
+	if err != nil {
+		os.Remove(fs.replayPath(id))
+		return Entry{}, fmt.Errorf("failed to write the entries: %v", err)
+	}
+	fs.entries = entries
+
+	return entry, nil
+}
+
+// Top returns the best entries for the game mode sorted by score.
+func (fs *FileStore) Top(mode string, limit int) []Entry {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	top := []Entry{}
+	for _, e := range fs.entries {
+		if e.Mode == mode {
+			top = append(top, e)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Score > top[j].Score
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// Replay returns the replay blob for the entry id.
+func (fs *FileStore) Replay(id string) ([]byte, error) {
+	if !isValidEntryID(id) {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(fs.replayPath(id))
+}
+
+func (fs *FileStore) replayPath(id string) string {
+	return filepath.Join(fs.dir, replaysDirName, id+replayFileExt)
+}
+
+// isValidEntryID makes sure an id from a request can't escape the store directory.
+func isValidEntryID(id string) bool {
+	if len(id) != 16 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// tempStoreDir returns a new temporary directory for a store, removed by
+// the returned function.
+func tempStoreDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "leaderboard-test-")
+	if err != nil {
+		t.Fatalf("failed to create a temporary directory: %v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// testSubmission returns a valid submission for the tests.
+func testSubmission(name string, mode string, score int64) *Submission {
+	return &Submission{
+		Name:     name,
+		Mode:     mode,
+		Seed:     42,
+		Score:    score,
+		Distance: float64(score),
+		Duration: 12.5,
+		Date:     time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC),
+		Replay:   []byte("replay of " + name),
+	}
+}
+
+func TestFileStorePersistsAcrossReopen(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+
+	fs, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	low, err := fs.Add(testSubmission("low", "normal", 100))
+	if err != nil {
+		t.Fatalf("failed to add an entry: %v", err)
+	}
+	high, err := fs.Add(testSubmission("high", "normal", 300))
+	if err != nil {
+		t.Fatalf("failed to add an entry: %v", err)
+	}
+	_, err = fs.Add(testSubmission("daily", "daily", 200))
+	if err != nil {
+		t.Fatalf("failed to add an entry: %v", err)
+	}
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen the store: %v", err)
+	}
+	top := reopened.Top("normal", 10)
+	if len(top) != 2 || top[0].ID != high.ID || top[1].ID != low.ID {
+		t.Fatalf("the reopened store has the wrong normal entries: %+v", top)
+	}
+	if top[0].Name != "high" || top[0].Score != 300 || !top[0].Date.Equal(high.Date) {
+		t.Errorf("the reopened entry doesn't match the added one: %+v", top[0])
+	}
+	if daily := reopened.Top("daily", 10); len(daily) != 1 || daily[0].Name != "daily" {
+		t.Errorf("the reopened store has the wrong daily entries: %+v", daily)
+	}
+
+	blob, err := reopened.Replay(low.ID)
+	if err != nil {
+		t.Fatalf("failed to read the replay from the reopened store: %v", err)
+	}
+	if !bytes.Equal(blob, []byte("replay of low")) {
+		t.Errorf("the replay is %q", blob)
+	}
+}
+
+func TestFileStoreTopLimit(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+
+	fs, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	for _, score := range []int64{5, 50, 20, 40} {
+		_, err := fs.Add(testSubmission("player", "normal", score))
+		if err != nil {
+			t.Fatalf("failed to add an entry: %v", err)
+		}
+	}
+
+	top := fs.Top("normal", 2)
+	if len(top) != 2 || top[0].Score != 50 || top[1].Score != 40 {
+		t.Errorf("expected the two best scores, got %+v", top)
+	}
+}
+
+func TestFileStoreReplayRejectsBadIDs(t *testing.T) {
+	dir, cleanup := tempStoreDir(t)
+	defer cleanup()
+
+	fs, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	for _, id := range []string{"", "../entries.json", "0123456789abcdeg"} {
+		_, err := fs.Replay(id)
+		if !os.IsNotExist(err) {
+			t.Errorf("expected no replay for %q, got %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package leaderboard implements an HTTP+JSON leaderboard for comparing runs
+// of the game: a client the game uses to submit runs and fetch the top lists,
+// and a reference server backed by a file store.
+//
+// The API is:
+//
+//	POST /api/scores                 submit a Submission; returns the stored Entry
+//	GET  /api/scores?mode=M&limit=N  returns the top Entry list for a game mode
+//	GET  /api/replays/{id}           returns the replay blob for an entry
+//
+// Servers with a Verifier re-simulate submitted runs from their replays and
+// answer 422 Unprocessable Entity with the report for runs that don't hold
+// up or can't be checked at all, such as for a corrupt replay. Clients don't
+// retry 4xx answers; 5xx answers are only for failures of the server itself.
+package leaderboard
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultLimit is the number of entries returned when no limit is requested.
+	DefaultLimit = 10
+
+	// MaxLimit is the largest number of entries that can be requested.
+	MaxLimit = 100
+
+	// MaxReplaySize is the largest replay blob accepted by the server.
+	MaxReplaySize = 8 * 1024 * 1024
+
+	maxNameLen = 32
+	maxModeLen = 16
+)
+
+// Submission is a run of the game submitted to the leaderboard.
+type Submission struct {
+	Name     string
+	Mode     string
+	Seed     int64
+	Score    int64
+	Distance float64
+	Duration float64 // seconds
+	Date     time.Time
+
+	// Replay is the encoded replay blob of the run.
+	Replay []byte
+}
+
+// Entry is a run stored on the leaderboard.
+type Entry struct {
+	ID       string
+	Name     string
+	Mode     string
+	Seed     int64
+	Score    int64
+	Distance float64
+	Duration float64 // seconds
+	Date     time.Time
+
+	// Submitted is when the server received the run.
+	Submitted time.Time
+}
+
+// Validate checks the submission for obviously bad data.
+func (s *Submission) Validate() error {
+	if s.Name == "" || len(s.Name) > maxNameLen {
+		return fmt.Errorf("name must be between 1 and %d bytes", maxNameLen)
+	}
+	if s.Mode == "" || len(s.Mode) > maxModeLen {
+		return fmt.Errorf("mode must be between 1 and %d bytes", maxModeLen)
+	}
+	if s.Score < 0 || s.Distance < 0.0 || s.Duration < 0.0 {
+		return fmt.Errorf("score, distance and duration must not be negative")
+	}
+	if len(s.Replay) == 0 {
+		return fmt.Errorf("a replay is required")
+	}
+	if len(s.Replay) > MaxReplaySize {
+		return fmt.Errorf("replay is larger than %d bytes", MaxReplaySize)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	scoresPath  = "/api/scores"
+	replaysPath = "/api/replays/"
+
+	// maxRequestSize caps the size of a submission request body; the replay
+	// is base64 encoded in the JSON so it's bigger than MaxReplaySize.
+	maxRequestSize = MaxReplaySize*2 + 64*1024
+)
+
+// Server implements http.Handler for the leaderboard API.
+type Server struct {
+	// Verifier checks the submitted runs before they're stored if it's non-nil.
+	Verifier Verifier
+
+	store *FileStore
+	mux   *http.ServeMux
+}
+
+// NewServer creates a new leaderboard server backed by the store.
+func NewServer(store *FileStore) *Server {
+	s := new(Server)
+	s.store = store
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc(scoresPath, s.handleScores)
+	s.mux.HandleFunc(replaysPath, s.handleReplay)
+	return s
+}
+
+// ServeHTTP dispatches the request to the API handlers.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleScores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetScores(w, r)
+	case http.MethodPost:
+		s.handlePostScore(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *Server) handleGetScores(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("a mode is required"))
+		return
+	}
+
+	limit := DefaultLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", MaxLimit))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.store.Top(mode, limit))
+}
+
+func (s *Server) handlePostScore(w http.ResponseWriter, r *http.Request) {
+	var sub Submission
+	err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&sub)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse the submission: %v", err))
+		return
+	}
+
+	err = sub.Validate()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// runs that are rejected or can't be checked are answered with a 4xx so
+	// that clients don't keep retrying them; only failures of the verifier
+	// itself are server errors.
+	if s.Verifier != nil {
+		err = s.Verifier.Verify(&sub)
+		switch verr := err.(type) {
+		case nil:
+		case *RejectedError:
+			log.Printf("rejected run from %s scoring %d (%s, seed %d):\n%s", sub.Name, sub.Score, sub.Mode, sub.Seed, verr.Report)
+			writeError(w, http.StatusUnprocessableEntity, verr)
+			return
+		case *UnverifiableError:
+			log.Printf("unverifiable run from %s scoring %d (%s, seed %d):\n%s", sub.Name, sub.Score, sub.Mode, sub.Seed, verr.Report)
+			writeError(w, http.StatusUnprocessableEntity, verr)
+			return
+		default:
+			log.Printf("failed to verify a submission: %v", err)
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to verify the submission"))
+			return
+		}
+	}
+
+	entry, err := s.store.Add(&sub)
+	if err != nil {
+		log.Printf("failed to store a submission: %v", err)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to store the submission"))
+		return
+	}
+
+	log.Printf("stored run %s: %s scored %d (%s, seed %d)", entry.ID, entry.Name, entry.Score, entry.Mode, entry.Seed)
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, replaysPath)
+	blob, err := s.store.Replay(id)
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no replay for %q", id))
+		return
+	} else if err != nil {
+		log.Printf("failed to read replay %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to read the replay"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(blob)
+}
+
+// errorResponse is the JSON body sent back for failed requests.
+type errorResponse struct {
+	Error string
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,153 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// verifierFunc adapts a function to the Verifier interface.
+type verifierFunc func(sub *Submission) error
+
+func (f verifierFunc) Verify(sub *Submission) error {
+	return f(sub)
+}
+
+// newTestServer starts a leaderboard server on a new store and returns a
+// client for it that doesn't wait between retries.
+func newTestServer(t *testing.T, verifier Verifier) (*httptest.Server, *Client, func()) {
+	dir, cleanup := tempStoreDir(t)
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		cleanup()
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	server := NewServer(store)
+	server.Verifier = verifier
+	ts := httptest.NewServer(server)
+
+	client, err := NewClient(ts.URL, "")
+	if err != nil {
+		ts.Close()
+		cleanup()
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	client.RetryDelay = time.Millisecond
+	return ts, client, func() {
+		ts.Close()
+		cleanup()
+	}
+}
+
+func TestSubmitAndFetch(t *testing.T) {
+	_, client, cleanup := newTestServer(t, nil)
+	defer cleanup()
+
+	first, err := client.Submit(testSubmission("first", "normal", 150))
+	if err != nil {
+		t.Fatalf("failed to submit a run: %v", err)
+	}
+	if first.ID == "" || first.Name != "first" || first.Score != 150 {
+		t.Errorf("the stored entry doesn't match the submission: %+v", first)
+	}
+	_, err = client.Submit(testSubmission("second", "normal", 400))
+	if err != nil {
+		t.Fatalf("failed to submit a run: %v", err)
+	}
+	_, err = client.Submit(testSubmission("daily", "daily", 999))
+	if err != nil {
+		t.Fatalf("failed to submit a run: %v", err)
+	}
+
+	top, err := client.Top("normal", 10)
+	if err != nil {
+		t.Fatalf("failed to fetch the top list: %v", err)
+	}
+	if len(top) != 2 || top[0].Name != "second" || top[1].Name != "first" {
+		t.Fatalf("the top list is wrong: %+v", top)
+	}
+
+	top, err = client.Top("normal", 1)
+	if err != nil {
+		t.Fatalf("failed to fetch the top list: %v", err)
+	}
+	if len(top) != 1 || top[0].Name != "second" {
+		t.Errorf("the limited top list is wrong: %+v", top)
+	}
+
+	blob, err := client.FetchReplay(first.ID)
+	if err != nil {
+		t.Fatalf("failed to fetch the replay: %v", err)
+	}
+	if !bytes.Equal(blob, []byte("replay of first")) {
+		t.Errorf("the fetched replay is %q", blob)
+	}
+	if client.Pending() != 0 {
+		t.Errorf("expected nothing queued, got %d", client.Pending())
+	}
+}
+
+func TestSubmitInvalid(t *testing.T) {
+	_, client, cleanup := newTestServer(t, nil)
+	defer cleanup()
+
+	sub := testSubmission("", "normal", 10)
+	_, err := client.Submit(sub)
+	se, okay := err.(*statusError)
+	if !okay || se.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request, got %v", err)
+	}
+	if client.Pending() != 0 {
+		t.Errorf("an invalid run was queued")
+	}
+}
+
+func TestVerifierAnswers(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		queued bool
+	}{
+		{"rejected", &RejectedError{Report: "the ship never died"}, http.StatusUnprocessableEntity, false},
+		{"unverifiable", &UnverifiableError{Report: "corrupt replay"}, http.StatusUnprocessableEntity, false},
+		{"verifier failure", fmt.Errorf("out of disk space"), http.StatusInternalServerError, true},
+	}
+
+	for _, test := range tests {
+		verifyErr := test.err
+		_, client, cleanup := newTestServer(t, verifierFunc(func(sub *Submission) error {
+			return verifyErr
+		}))
+
+		_, err := client.Submit(testSubmission("cheater", "normal", 1000))
+		if test.queued {
+			if err != ErrQueued {
+				t.Errorf("%s: expected the run to be queued, got %v", test.name, err)
+			}
+		} else {
+			se, okay := err.(*statusError)
+			if !okay || se.StatusCode != test.status {
+				t.Errorf("%s: expected a %d, got %v", test.name, test.status, err)
+			}
+			if isTransient(err) {
+				t.Errorf("%s: the answer would be retried", test.name)
+			}
+		}
+		if queued := client.Pending() == 1; queued != test.queued {
+			t.Errorf("%s: expected queued to be %v", test.name, test.queued)
+		}
+
+		top, err := client.Top("normal", 10)
+		if err != nil || len(top) != 0 {
+			t.Errorf("%s: the run was stored: %+v, %v", test.name, top, err)
+		}
+		cleanup()
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package leaderboard
+
+import (
+	"fmt"
+)
+
+// Verifier checks submitted runs before they're stored on the leaderboard.
+type Verifier interface {
+	// Verify returns a *RejectedError if the run doesn't hold up, an
+	// *UnverifiableError if the run itself can't be checked, such as for a
+	// corrupt replay, or any other error if the verifier failed.
+	Verify(sub *Submission) error
+}
+
+// RejectedError is returned by a Verifier for runs that don't match their replay.
+type RejectedError struct {
+	// Report explains why the run was rejected.
+	Report string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("the run was rejected by the verifier:\n%s", e.Report)
+}
+
+// UnverifiableError is returned by a Verifier for runs that can't be checked
+// because of the submission itself, such as a replay that can't be read.
+type UnverifiableError struct {
+	// Report explains why the run couldn't be checked.
+	Report string
+}
+
+func (e *UnverifiableError) Error() string {
+	return fmt.Sprintf("the run could not be verified:\n%s", e.Report)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"time"
@@ -19,6 +20,10 @@ import (
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 
 	input "github.com/tbogdala/fizzle/input/glfwinput"
+
+	"github.com/tbogdala/infinigrid/game"
+	"github.com/tbogdala/infinigrid/leaderboard"
+	"github.com/tbogdala/infinigrid/replay"
 )
 
 const (
@@ -28,13 +33,53 @@ const (
 
 var (
 	kbModel   *input.KeyboardModel
-	gameScene *GameScene
+	gameScene *game.GameScene
 
 	flagUseVR        = flag.Bool("vr", false, "run the game in VR mode")
 	flagUseSingleEye = flag.Bool("oneeye", false, "uses a single-eye view for the application window in VR")
 	flagCPUProfile   = flag.String("cpuprofile", "", "provide a filename for the output pprof file")
+
+	flagUseMouse         = flag.Bool("mouse", false, "steer the ship with the mouse in addition to the keyboard")
+	flagMouseSensitivity = flag.Float64("mousesens", 1.0, "scales how far the mouse needs to move to steer the ship")
+	flagMouseInvert      = flag.Bool("mouseinvert", false, "inverts the mouse pitch so moving the mouse up points the ship down")
+	flagMouseCapture     = flag.Bool("mousecapture", false, "hides the cursor and steers with relative mouse motion")
+
+	flagSeed       = flag.Int64("seed", 0, "seeds every run with this value instead of a random one")
+	flagDaily      = flag.Bool("daily", false, "plays the daily challenge which uses the same seed for everyone each day")
+	flagPlayerName = flag.String("name", "", "the player name to store in the high score table")
+
+	flagLeaderboardURL = flag.String("leaderboard", "", "the address of an online leaderboard server to submit runs to")
+	flagTelemetry      = flag.Bool("telemetry", false, "writes an event log for each run to the runs directory in the user data directory")
+	flagTelemetryDir   = flag.String("telemetrydir", "", "writes an event log for each run to this directory")
+	flagGhost          = flag.String("ghost", "", "races a ghost of the personal best run with 'best' or of the replay file specified")
+
+	flagVerify        = flag.String("verify", "", "re-simulates the replay file without graphics, checks it against the claim flags and exits")
+	flagClaimSeed     = flag.Int64("claimseed", 0, "the seed claimed for the run being verified; defaults to the replay's seed")
+	flagClaimMode     = flag.String("claimmode", "", "the game mode claimed for the run being verified; defaults to the replay's mode")
+	flagClaimScore    = flag.Int64("claimscore", 0, "the score claimed for the run being verified")
+	flagClaimDistance = flag.Float64("claimdistance", 0, "the distance claimed for the run being verified")
+
+	flagAssets   = flag.String("assets", "", "a list of asset directories, separated like PATH, that override the game's assets in order")
+	flagTextures = flag.String("textures", game.TextureQualityLow, "the texture quality to use: 2k or 512")
+	flagDev      = flag.Bool("dev", false, "dev mode: reloads the components when the asset files change")
+
+	flagValidateAssets = flag.Bool("validate-assets", false, "checks the components and the files they use, prints a report and exits")
+
+	flagAnalyze    = flag.String("analyze", "", "aggregates the run logs and replays in the directory, reports where runs ended and exits")
+	flagAnalyzeOut = flag.String("analyzeout", "heatmaps", "the directory the -analyze heatmap images are written to")
+)
+
+// exit codes for the -verify command
+const (
+	verifyExitAccepted   = 0
+	verifyExitRejected   = 1
+	verifyExitError      = 2
+	verifyExitUnreadable = 3
 )
 
+// minFrameTime is the shortest frame the main loop plays.
+const minFrameTime = time.Duration(game.MinFrameDelta * float64(time.Second))
+
 func init() {
 	runtime.LockOSThread()
 }
@@ -43,6 +88,32 @@ func main() {
 	var err error
 	flag.Parse()
 
+	// extra asset roots take priority over the game's own assets
+	if *flagAssets != "" {
+		game.AssetRoots = append(filepath.SplitList(*flagAssets), game.DefaultAssetRoot)
+	}
+
+	// verifying and analyzing runs don't need any graphics so they're done
+	// before any windows get created.
+	if *flagVerify != "" {
+		os.Exit(verifyReplayFile(*flagVerify))
+	}
+	if *flagAnalyze != "" {
+		os.Exit(analyzeRuns(*flagAnalyze, *flagAnalyzeOut))
+	}
+	if *flagValidateAssets {
+		os.Exit(validateAssetsCommand())
+	}
+
+	// make sure the assets can be loaded before creating the window so
+	// that a broken checkout gets a useful report instead of a crash.
+	assetReport := game.ValidateAssets(game.AssetRoots)
+	if assetReport.HasErrors() {
+		fmt.Printf("The assets failed validation.\n\n")
+		assetReport.Write(os.Stdout)
+		os.Exit(1)
+	}
+
 	// potentially enable cpu profiling
 	if *flagCPUProfile != "" {
 		fmt.Printf("Enabling CPU Profiling!\n")
@@ -61,9 +132,18 @@ func main() {
 	// seed the RNG
 	rand.Seed(time.Now().UnixNano())
 
+	////////////////////////////////////////////////////////////////////////////
+	// create a scene manager; the systems are added to it once they're set up
+	gameScene = game.NewGameScene()
+
 	var renderSystem RenderSystem
 	var renderSceneSystem scene.System
+
+	// the ship controller flies the ship based on the intents from all of
+	// the input systems.
+	shipController := game.NewShipController(gameScene)
 	var inputSceneSystem scene.System
+	var mouseSceneSystem scene.System
 	var uiSceneSystem scene.System
 
 	// setup vr mode if indicated via command line flag
@@ -86,14 +166,26 @@ func main() {
 
 		// create the vr input system to handle the vr controllers
 		vrInputSystem := NewVRInputSystem()
-		vrInputSystem.Initialize(vrRenderSystem)
+		vrInputSystem.Initialize(vrRenderSystem, shipController)
 
 		// wire some inputs for the vive wands
 		vrInputSystem.OnAppMenuButtonL = vrInputSystem.HandleMenuButtonInput
 
+		// keep the HMD glued to the ship as it moves
+		shipController.OnShipMoved = vrInputSystem.HandleShipMoved
+
+		// toasts are shown on a panel in the world
+		vrPanelSystem := NewVRPanelSystem()
+		err = vrPanelSystem.Initialize(vrRenderSystem)
+		if err != nil {
+			fmt.Printf("Failed to initialize the VR panel! %v", err)
+			return
+		}
+
 		renderSystem = vrRenderSystem
 		renderSceneSystem = vrRenderSystem
 		inputSceneSystem = vrInputSystem
+		uiSceneSystem = vrPanelSystem
 	} else {
 		// no vr flag was specified so construct a normal renderer
 		forwardRenderSystem := NewForwardRenderSystem()
@@ -105,7 +197,17 @@ func main() {
 
 		// create the keyboard interface to the game
 		kbInputSystem := NewKeyboardInputSystem()
-		kbInputSystem.Initialize(forwardRenderSystem.GetMainWindow())
+		kbInputSystem.Initialize(forwardRenderSystem.GetMainWindow(), shipController)
+
+		// optionally steer the ship with the mouse as well
+		if *flagUseMouse {
+			mouseInputSystem := NewMouseInputSystem()
+			mouseInputSystem.Sensitivity = float32(*flagMouseSensitivity)
+			mouseInputSystem.Invert = *flagMouseInvert
+			mouseInputSystem.Captured = *flagMouseCapture
+			mouseInputSystem.Initialize(forwardRenderSystem.GetMainWindow(), shipController)
+			mouseSceneSystem = mouseInputSystem
+		}
 
 		// use a 'traditional' user interface system for the game UI
 		uisys := NewUISystem()
@@ -122,10 +224,88 @@ func main() {
 	}
 
 	////////////////////////////////////////////////////////////////////////////
-	// create a scene manager
-	gameScene = NewGameScene()
+	// configure the runs played in the scene
+	gameScene.FixedSeed = *flagSeed
+	gameScene.UseFixedSeed = *flagSeed != 0
+	if *flagDaily {
+		gameScene.GameMode = game.GameModeDaily
+	}
+	gameScene.PlayerName = getPlayerName()
+	err = gameScene.SetTextureQuality(*flagTextures)
+	if err != nil {
+		fmt.Printf("Failed to set the texture quality. %v\n", err)
+		return
+	}
+
+	// load the high score table from the user data directory
+	dataDir, err := game.UserDataDir()
+	if err != nil {
+		fmt.Printf("High scores will not be saved. %v\n", err)
+	} else {
+		gameScene.HighScores, err = game.LoadHighScoreTable(filepath.Join(dataDir, game.HighScoreFileName), game.HighScoreMaxEntries)
+		if err != nil {
+			fmt.Printf("Starting with an empty high score table. %v\n", err)
+		}
+	}
+
+	// event logs for the runs go to the user data directory unless
+	// another directory is given.
+	if *flagTelemetryDir != "" {
+		gameScene.TelemetryDir = *flagTelemetryDir
+	} else if *flagTelemetry && dataDir != "" {
+		gameScene.TelemetryDir = filepath.Join(dataDir, game.TelemetryDirName)
+	}
+
+	// track the achievements with the progress kept in the user data directory
+	achievementDefs, err := game.LoadGameAchievementDefs()
+	if err != nil {
+		fmt.Printf("Achievements are disabled. %v\n", err)
+	} else if dataDir != "" {
+		gameScene.Achievements, err = game.NewAchievementTracker(achievementDefs, filepath.Join(dataDir, game.AchievementsFileName))
+		if err != nil {
+			fmt.Printf("Starting with no achievements unlocked. %v\n", err)
+		}
+		gameScene.Achievements.OnUnlock = func(def *game.AchievementDef) {
+			gameScene.ShowToast("ACHIEVEMENT UNLOCKED: "+def.Name, def.Description)
+		}
+	}
+
+	// load the personal best for the game mode and the ghost to race
+	if dataDir != "" {
+		gameScene.PersonalBestFile = filepath.Join(dataDir, fmt.Sprintf(game.GhostFileFormat, gameScene.GameMode))
+		if _, err := os.Stat(gameScene.PersonalBestFile); err == nil {
+			gameScene.PersonalBest = loadGhost(gameScene.PersonalBestFile, gameScene.GameMode)
+		}
+	}
+	switch *flagGhost {
+	case "":
+	case "best":
+		gameScene.RaceBest = true
+		gameScene.Ghost = gameScene.PersonalBest
+	default:
+		gameScene.Ghost = loadGhost(*flagGhost, gameScene.GameMode)
+	}
+
+	// connect to the online leaderboard; runs that can't be sent are queued
+	// in the user data directory and retried later.
+	if *flagLeaderboardURL != "" {
+		queueFile := ""
+		if dataDir != "" {
+			queueFile = filepath.Join(dataDir, game.LeaderboardQueueFileName)
+		}
+		client, err := leaderboard.NewClient(*flagLeaderboardURL, queueFile)
+		if err != nil {
+			fmt.Printf("Problem with the leaderboard submission queue. %v\n", err)
+		}
+		gameScene.Leaderboard = game.NewLeaderboardSync(client, gameScene.GameMode)
+	}
+
 	gameScene.AddSystem(renderSceneSystem)
+	if mouseSceneSystem != nil {
+		gameScene.AddSystem(mouseSceneSystem)
+	}
 	gameScene.AddSystem(inputSceneSystem)
+	gameScene.AddSystem(shipController)
 	gameScene.AddSystem(uiSceneSystem)
 
 	// create some objects and lights
@@ -134,6 +314,12 @@ func main() {
 		fmt.Printf("Failed to setup the game scene. %v\n", err)
 		return
 	}
+	if *flagDev {
+		gameScene.AssetWatcher = game.NewAssetWatcher(game.AssetRoots)
+	}
+
+	// start at the main menu if there's a user interface for it
+	gameScene.ShowMainMenu()
 
 	////////////////////////////////////////////////////////////////////////////
 	// set the callback functions for key input common to all input systems
@@ -142,16 +328,30 @@ func main() {
 	kbModel.BindTrigger(glfw.KeyEscape, func() {
 		mainWindow.SetShouldClose(true)
 	})
+	kbModel.BindTrigger(glfw.KeyP, func() {
+		gameScene.TogglePause()
+	})
 	kbModel.SetupCallbacks()
 
 	////////////////////////////////////////////////////////////////////////////
 	// the main application loop
 	lastFrame := time.Now()
 	for !mainWindow.ShouldClose() && !gameScene.ShouldClose {
-		// calculate the difference in time to control rotation speed
+		// calculate the difference in time to control rotation speed,
+		// waiting out frames shorter than a replay may have
 		thisFrame := time.Now()
+		if wait := lastFrame.Add(minFrameTime).Sub(thisFrame); wait > 0 {
+			time.Sleep(wait)
+			thisFrame = time.Now()
+		}
 		frameDelta := float32(thisFrame.Sub(lastFrame).Seconds())
 
+		// a long hitch is played as the longest frame a replay may have so
+		// that the recorded run can still be verified.
+		if frameDelta > game.MaxFrameDelta {
+			frameDelta = game.MaxFrameDelta
+		}
+
 		handleInput()
 
 		// update the game scene
@@ -164,9 +364,95 @@ func main() {
 		mainWindow.SwapBuffers()
 	}
 
+	// finish the event log of a run that was quit part way through
+	gameScene.CloseTelemetry()
+	if gameScene.Achievements != nil {
+		err = gameScene.Achievements.Save()
+		if err != nil {
+			fmt.Printf("Could not save the achievements: %v\n", err)
+		}
+	}
+
 	vr.Shutdown()
 }
 
+// getPlayerName returns the player name from the command line flag or
+// falls back to the name of the user running the game.
+func getPlayerName() string {
+	if *flagPlayerName != "" {
+		return *flagPlayerName
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "Player"
+}
+
+// verifyReplayFile re-simulates the replay file against the claim flags,
+// prints the report and returns the exit code for the process.
+func verifyReplayFile(filename string) int {
+	rep, err := replay.LoadFile(filename)
+	if err != nil {
+		fmt.Printf("Failed to load the replay. %v\n", err)
+		return verifyExitUnreadable
+	}
+
+	claim := game.RunClaim{
+		Seed:     rep.Seed,
+		Mode:     rep.Mode,
+		Score:    *flagClaimScore,
+		Distance: *flagClaimDistance,
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "claimseed":
+			claim.Seed = *flagClaimSeed
+		case "claimmode":
+			claim.Mode = *flagClaimMode
+		}
+	})
+
+	report, err := game.VerifyRun(rep, claim)
+	if err != nil {
+		fmt.Printf("Failed to verify the replay. %v\n", err)
+		return verifyExitError
+	}
+	fmt.Print(report.String())
+	if !report.Accepted {
+		return verifyExitRejected
+	}
+	return verifyExitAccepted
+}
+
+// validateAssetsCommand validates the assets, prints the report and returns
+// the exit code for the process.
+func validateAssetsCommand() int {
+	report := game.ValidateAssets(game.AssetRoots)
+	report.Write(os.Stdout)
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// loadGhost loads the ghost track from the replay file returning nil if
+// it can't be used for the game mode. Daily challenge ghosts have to be
+// from today's challenge.
+func loadGhost(filename string, mode string) *game.GhostTrack {
+	ghost, err := game.LoadGhostTrack(filename)
+	if err != nil {
+		fmt.Printf("Could not load the ghost. %v\n", err)
+		return nil
+	}
+	if mode == game.GameModeDaily && ghost.Seed != game.DailySeed(time.Now()) {
+		fmt.Printf("The ghost in %s isn't from today's daily challenge.\n", filename)
+		return nil
+	}
+	return ghost
+}
+
 func handleInput() {
 	// advise GLFW to poll for input. without this the window appears to hang.
 	glfw.PollEvents()
@@ -0,0 +1,179 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	glfw "github.com/go-gl/glfw/v3.1/glfw"
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/game"
+)
+
+const (
+	mouseInputSystemPriority = -100.0
+	mouseInputSystemName     = "MouseInputSystem"
+)
+
+var (
+	// scales the cursor motion in captured mode; this many pixels of motion
+	// at a sensitivity of 1.0 will move the target from neutral to max.
+	mouseCapturedRangePx = float32(300.0)
+)
+
+// MouseInputSystem implements the System interface and steers the
+// player's ship with the mouse.
+//
+// By default the offset of the cursor from the center of the window
+// determines the target roll and pitch. If Captured is set the cursor
+// is hidden and relative motion is accumulated into the target instead.
+type MouseInputSystem struct {
+	// Sensitivity scales how far the cursor needs to move to reach
+	// the maximum roll and pitch.
+	Sensitivity float32
+
+	// Invert will flip the pitch so that moving the mouse up points
+	// the nose of the ship down.
+	Invert bool
+
+	// Captured enables relative motion with a hidden cursor.
+	Captured bool
+
+	// RecentreKey is the key that resets the mouse target to neutral.
+	RecentreKey glfw.Key
+
+	// BoostButton is the mouse button that will boost the ship while held.
+	BoostButton glfw.MouseButton
+
+	mainWindow *glfw.Window
+
+	// controller is the ship controller that receives the mouse intents.
+	controller *game.ShipController
+
+	// targetRoll and targetPitch are the normalized [-1..1] targets
+	// derived from the mouse.
+	targetRoll  float32
+	targetPitch float32
+
+	lastCursorX      float64
+	lastCursorY      float64
+	cursorIsCaptured bool
+	recentreWasDown  bool
+}
+
+// NewMouseInputSystem creates a new MouseInputSystem object
+func NewMouseInputSystem() *MouseInputSystem {
+	system := new(MouseInputSystem)
+	system.Sensitivity = 1.0
+	system.RecentreKey = glfw.KeyC
+	system.BoostButton = glfw.MouseButtonLeft
+	return system
+}
+
+// Initialize sets up the mouse for steering in the window.
+func (s *MouseInputSystem) Initialize(w *glfw.Window, controller *game.ShipController) {
+	s.mainWindow = w
+	s.controller = controller
+	s.Recentre()
+}
+
+// Recentre resets the mouse target back to neutral. In uncaptured mode this
+// moves the cursor back to the center of the window.
+func (s *MouseInputSystem) Recentre() {
+	s.targetRoll = 0.0
+	s.targetPitch = 0.0
+
+	if !s.Captured {
+		w, h := s.mainWindow.GetSize()
+		s.mainWindow.SetCursorPos(float64(w)/2.0, float64(h)/2.0)
+	}
+	s.lastCursorX, s.lastCursorY = s.mainWindow.GetCursorPos()
+}
+
+// setCursorCaptured hides and locks the cursor to the window while playing
+// in captured mode and releases it otherwise so that menus can be used.
+func (s *MouseInputSystem) setCursorCaptured(capture bool) {
+	if capture == s.cursorIsCaptured {
+		return
+	}
+	s.cursorIsCaptured = capture
+	if capture {
+		s.mainWindow.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	} else {
+		s.mainWindow.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+	s.lastCursorX, s.lastCursorY = s.mainWindow.GetCursorPos()
+}
+
+// Update should get called to run updates for the system every frame
+// by the owning Manager object.
+func (s *MouseInputSystem) Update(frameDelta float32) {
+	// only steer while the game is being played
+	if gameScene.State() != game.GameStatePlaying {
+		s.setCursorCaptured(false)
+		return
+	}
+	s.setCursorCaptured(s.Captured)
+
+	// check for the recentre key being pressed
+	recentreDown := s.mainWindow.GetKey(s.RecentreKey) == glfw.Press
+	if recentreDown && !s.recentreWasDown {
+		s.Recentre()
+	}
+	s.recentreWasDown = recentreDown
+
+	x, y := s.mainWindow.GetCursorPos()
+	if s.Captured {
+		// accumulate the relative motion into the target
+		dx := float32(x-s.lastCursorX) / mouseCapturedRangePx
+		dy := float32(y-s.lastCursorY) / mouseCapturedRangePx
+		s.targetRoll = mgl.Clamp(s.targetRoll+dx*s.Sensitivity, -1.0, 1.0)
+		s.targetPitch = mgl.Clamp(s.targetPitch+dy*s.Sensitivity, -1.0, 1.0)
+	} else {
+		// use the offset from the center of the window as the target
+		w, h := s.mainWindow.GetSize()
+		halfW, halfH := float64(w)/2.0, float64(h)/2.0
+		if halfW > 0.0 && halfH > 0.0 {
+			s.targetRoll = mgl.Clamp(float32((x-halfW)/halfW)*s.Sensitivity, -1.0, 1.0)
+			s.targetPitch = mgl.Clamp(float32((y-halfH)/halfH)*s.Sensitivity, -1.0, 1.0)
+		}
+	}
+	s.lastCursorX, s.lastCursorY = x, y
+
+	// screen space y grows downwards, so by default moving the mouse
+	// up pitches the nose of the ship up.
+	intent := game.ShipIntent{
+		Roll:     s.targetRoll,
+		Pitch:    s.targetPitch,
+		Absolute: true,
+	}
+	if s.Invert {
+		intent.Pitch = -intent.Pitch
+	}
+	if s.mainWindow.GetMouseButton(s.BoostButton) == glfw.Press {
+		intent.Boost = 1.0
+	}
+	s.controller.AddIntent(intent)
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (s *MouseInputSystem) OnAddEntity(newEntity scene.Entity) {}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (s *MouseInputSystem) OnRemoveEntity(oldEntity scene.Entity) {}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (s *MouseInputSystem) GetRequestedPriority() float32 {
+	return mouseInputSystemPriority
+}
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (s *MouseInputSystem) GetName() string {
+	return mouseInputSystemName
+}
@@ -6,7 +6,11 @@ package main
 import (
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
+	fizzle "github.com/tbogdala/fizzle"
 	forward "github.com/tbogdala/fizzle/renderer/forward"
+	scene "github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 var (
@@ -22,3 +26,24 @@ type RenderSystem interface {
 	GetRenderer() *forward.ForwardRenderer
 	GetMainWindow() *glfw.Window
 }
+
+// drawVisibleEntities calls draw for the renderable of each entity that has
+// one, drawing the translucent entities after the opaque ones.
+func drawVisibleEntities(entities []scene.Entity, draw func(r *fizzle.Renderable)) {
+	for _, translucentPass := range []bool{false, true} {
+		for _, e := range entities {
+			visibleEntity, okay := e.(game.RenderableEntity)
+			if !okay {
+				continue
+			}
+			translucentEntity, okay := e.(game.TranslucentEntity)
+			translucent := okay && translucentEntity.IsTranslucent()
+			if translucent != translucentPass {
+				continue
+			}
+			if r := visibleEntity.GetRenderable(); r != nil {
+				draw(r)
+			}
+		}
+	}
+}
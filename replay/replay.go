@@ -0,0 +1,223 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package replay implements the recording format for runs of the game.
+//
+// A replay stores the seed of the run along with the frame delta and the
+// merged flight controls for every simulated frame, which is enough to
+// re-simulate the run deterministically.
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// Version is the current version of the replay format.
+	Version = 1
+
+	// magic identifies the start of a replay blob.
+	magic = "IGRP"
+
+	// maxStringLen caps the length of strings read from a replay.
+	maxStringLen = 1024
+
+	// MaxFrames caps the frames read from a replay so that a small blob
+	// can't decompress into an endless run: an hour of play at 500 frames
+	// a second, the most frames the game records.
+	MaxFrames = 60 * 60 * 500
+)
+
+// Frame is a single simulated frame of a run.
+type Frame struct {
+	// Delta is the frame delta in seconds.
+	Delta float32
+
+	// RollRate and PitchRate are the rate controls for the frame.
+	RollRate  float32
+	PitchRate float32
+
+	// HasTarget indicates the TargetRoll and TargetPitch controls are in use.
+	HasTarget   bool
+	TargetRoll  float32
+	TargetPitch float32
+
+	// Boost is the boost control for the frame.
+	Boost float32
+}
+
+// Replay is the recording of a single run.
+type Replay struct {
+	// Seed is the seed used for the random number generator of the run.
+	Seed int64
+
+	// Mode is the game mode of the run.
+	Mode string
+
+	// Frames are the simulated frames in order.
+	Frames []Frame
+}
+
+// New returns a new empty replay for a run with the seed and mode.
+func New(seed int64, mode string) *Replay {
+	r := new(Replay)
+	r.Seed = seed
+	r.Mode = mode
+	return r
+}
+
+// AddFrame appends a frame to the replay.
+func (r *Replay) AddFrame(f Frame) {
+	r.Frames = append(r.Frames, f)
+}
+
+// Duration returns the total of the frame deltas in seconds.
+func (r *Replay) Duration() float64 {
+	var total float64
+	for _, f := range r.Frames {
+		total += float64(f.Delta)
+	}
+	return total
+}
+
+// Encode serializes the replay to a compressed binary blob.
+func (r *Replay) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+
+	w := &errWriter{w: zw}
+	w.write([]byte(magic))
+	w.write(uint16(Version))
+	w.write(r.Seed)
+	w.writeString(r.Mode)
+	w.write(uint32(len(r.Frames)))
+	for i := range r.Frames {
+		w.write(&r.Frames[i])
+	}
+	if w.err != nil {
+		return nil, fmt.Errorf("failed to encode the replay: %v", w.err)
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress the replay: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode deserializes a replay from a blob created with Encode.
+func Decode(blob []byte) (*Replay, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the replay: %v", err)
+	}
+	defer zr.Close()
+
+	r := &errReader{r: zr}
+	header := make([]byte, len(magic))
+	r.read(header)
+	if r.err == nil && string(header) != magic {
+		return nil, fmt.Errorf("not a replay blob")
+	}
+
+	var version uint16
+	r.read(&version)
+	if r.err == nil && version != Version {
+		return nil, fmt.Errorf("unsupported replay version %d", version)
+	}
+
+	rep := new(Replay)
+	r.read(&rep.Seed)
+	rep.Mode = r.readString()
+
+	var frameCount uint32
+	r.read(&frameCount)
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode the replay header: %v", r.err)
+	}
+	if frameCount > MaxFrames {
+		return nil, fmt.Errorf("replay has %d frames, more than the %d allowed", frameCount, MaxFrames)
+	}
+
+	// frames are read one at a time so a corrupt count can't allocate
+	// more memory than the blob actually holds.
+	for i := uint32(0); i < frameCount; i++ {
+		var f Frame
+		r.read(&f)
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to decode replay frame %d: %v", i, r.err)
+		}
+		rep.Frames = append(rep.Frames, f)
+	}
+
+	// reading to the end of the stream verifies the checksum. only a byte
+	// is read so that trailing data can't decompress without bound.
+	trailing, err := ioutil.ReadAll(io.LimitReader(zr, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the replay: %v", err)
+	} else if len(trailing) > 0 {
+		return nil, fmt.Errorf("replay has trailing data")
+	}
+
+	return rep, nil
+}
+
+// LoadFile reads and decodes a replay from a file.
+func LoadFile(filename string) (*Replay, error) {
+	blob, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(blob)
+}
+
+// errWriter writes little-endian binary data until the first error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) write(data interface{}) {
+	if w.err != nil {
+		return
+	}
+	w.err = binary.Write(w.w, binary.LittleEndian, data)
+}
+
+func (w *errWriter) writeString(s string) {
+	w.write(uint16(len(s)))
+	w.write([]byte(s))
+}
+
+// errReader reads little-endian binary data until the first error.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errReader) read(data interface{}) {
+	if r.err != nil {
+		return
+	}
+	r.err = binary.Read(r.r, binary.LittleEndian, data)
+}
+
+func (r *errReader) readString() string {
+	var length uint16
+	r.read(&length)
+	if r.err != nil {
+		return ""
+	}
+	if length > maxStringLen {
+		r.err = fmt.Errorf("string length %d is too long", length)
+		return ""
+	}
+	buf := make([]byte, length)
+	r.read(buf)
+	return string(buf)
+}
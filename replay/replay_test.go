@@ -0,0 +1,79 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encodeRaw returns a replay blob whose header claims frameCount frames
+// followed by the frames given.
+func encodeRaw(t *testing.T, frameCount uint32, frames []Frame) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := &errWriter{w: zw}
+	w.write([]byte(magic))
+	w.write(uint16(Version))
+	w.write(int64(1))
+	w.writeString("normal")
+	w.write(frameCount)
+	for i := range frames {
+		w.write(&frames[i])
+	}
+	if w.err != nil {
+		t.Fatalf("failed to write the replay: %v", w.err)
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("failed to compress the replay: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeDecode(t *testing.T) {
+	rep := New(-1234, "daily")
+	rep.AddFrame(Frame{Delta: 1.0 / 60.0, RollRate: 0.5, Boost: 1.0})
+	rep.AddFrame(Frame{Delta: 1.0 / 30.0, HasTarget: true, TargetRoll: -0.25, TargetPitch: 0.75})
+
+	blob, err := rep.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode the replay: %v", err)
+	}
+	decoded, err := Decode(blob)
+	if err != nil {
+		t.Fatalf("failed to decode the replay: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, rep) {
+		t.Errorf("the decoded replay %+v doesn't match %+v", decoded, rep)
+	}
+}
+
+func TestDecodeFrameCount(t *testing.T) {
+	frames := []Frame{{Delta: 1.0 / 60.0}, {Delta: 1.0 / 60.0}}
+
+	tests := []struct {
+		name       string
+		frameCount uint32
+		err        string
+	}{
+		{"more frames than the data", 3, "failed to decode replay frame 2"},
+		{"more frames than allowed", MaxFrames + 1, "more than the"},
+		{"fewer frames than the data", 1, "trailing data"},
+	}
+	for _, test := range tests {
+		_, err := Decode(encodeRaw(t, test.frameCount, frames))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected an error with %q, got %v", test.name, test.err, err)
+		}
+	}
+
+	_, err := Decode(encodeRaw(t, 2, frames))
+	if err != nil {
+		t.Errorf("failed to decode the raw replay: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package telemetry implements the event log written for each run of the game.
+//
+// A run log is a JSON Lines file: one JSON object per line, each one an Event.
+// Every event has these fields:
+//
+//	type   string   the event type listed below
+//	t      number   the game time of the event in seconds since the run started
+//	dist   number   the distance travelled down the tunnel in meters
+//
+// and the other fields depend on the type. Fields that don't apply to an event
+// are left out.
+//
+//	run_start  the first event of every log.
+//	           run: {version, seed, mode, player, date}
+//	spawn      an entity was spawned in front of the ship.
+//	           entity, component, pos: [x, y, z]
+//	wave       a wave of bombs was spawned.
+//	           count
+//	near_miss  a bomb passed the ship without hitting it.
+//	           entity, component, pos, miss: the distance between the centers in the X/Y plane
+//	pickup     the ship collected a pickup, an entity with a "pickup" tagged collider.
+//	           entity, component, pos: the point of contact,
+//	           tags: the tags of the pickup collider, bonus: the score of its "bonus:<n>" tag
+//	hit        the ship collided with an entity.
+//	           entity, component, pos: the location of the ship,
+//	           contact: [x, y, z] roughly where the colliders touched
+//	speed      the forward speed of the ship changed by at least a meter per second.
+//	           speed
+//	death      the run ended; the last event of a log unless the game was quit.
+//	           entity: the name of the entity that killed the ship (e.g. Bomb_3_1520),
+//	           component, pos: the location of the ship
+//	frame      frame time samples covering about a second of play.
+//	           frames: {count, avg_ms, max_ms}
+//	trigger_enter
+//	           the ship flew into a trigger collider, such as a ring or a checkpoint.
+//	           entity: the name of the entity with the trigger, component,
+//	           pos: the location of the ship, tags: the tags of the trigger collider,
+//	           event: the scripted event of an "event:<name>" tag,
+//	           bonus: the score added by a ring
+//	trigger_exit
+//	           the ship left a trigger collider, or it was despawned.
+//	           entity, component, pos, tags, event as for trigger_enter
+//
+// The positions are in the space of the ship which stays at Z = 0 while the
+// level scrolls past it. SchemaVersion changes whenever a field is removed or
+// its meaning changes; new event types and fields may be added without a change.
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SchemaVersion is the version of the event log schema.
+const SchemaVersion = 1
+
+// The event types.
+const (
+	TypeRunStart = "run_start"
+	TypeSpawn    = "spawn"
+	TypeWave     = "wave"
+	TypeNearMiss = "near_miss"
+	TypePickup   = "pickup"
+	TypeHit      = "hit"
+	TypeSpeed    = "speed"
+	TypeDeath    = "death"
+	TypeFrame    = "frame"
+
+	TypeTriggerEnter = "trigger_enter"
+	TypeTriggerExit  = "trigger_exit"
+)
+
+// Vec3 is a position in the log.
+type Vec3 [3]float32
+
+// RunInfo describes the run in the run_start event.
+type RunInfo struct {
+	Version int       `json:"version"`
+	Seed    int64     `json:"seed"`
+	Mode    string    `json:"mode"`
+	Player  string    `json:"player,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// FrameStats are the frame time samples in the frame event.
+type FrameStats struct {
+	Count int     `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// Event is a single entry in a run log.
+type Event struct {
+	Type     string  `json:"type"`
+	Time     float64 `json:"t"`
+	Distance float64 `json:"dist"`
+
+	Entity    string  `json:"entity,omitempty"`
+	Component string  `json:"component,omitempty"`
+	Position  *Vec3   `json:"pos,omitempty"`
+	Contact   *Vec3   `json:"contact,omitempty"`
+	Miss      float64 `json:"miss,omitempty"`
+	Count     int     `json:"count,omitempty"`
+	Speed     float64 `json:"speed,omitempty"`
+
+	Tags          []string `json:"tags,omitempty"`
+	ScriptedEvent string   `json:"event,omitempty"`
+	Bonus         int64    `json:"bonus,omitempty"`
+
+	Run    *RunInfo    `json:"run,omitempty"`
+	Frames *FrameStats `json:"frames,omitempty"`
+}
+
+// Writer writes events to a run log.
+type Writer struct {
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewWriter returns a new writer that writes the events to w.
+func NewWriter(w io.Writer) *Writer {
+	tw := new(Writer)
+	tw.w = bufio.NewWriter(w)
+	tw.enc = json.NewEncoder(tw.w)
+	return tw
+}
+
+// Create creates the run log file and returns a writer for it.
+func Create(filename string) (*Writer, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	tw := NewWriter(f)
+	tw.closer = f
+	return tw, nil
+}
+
+// Write adds the event to the log.
+func (tw *Writer) Write(e *Event) error {
+	return tw.enc.Encode(e)
+}
+
+// Close flushes the log and closes the file if the writer was created with Create.
+func (tw *Writer) Close() error {
+	err := tw.w.Flush()
+	if tw.closer != nil {
+		if closeErr := tw.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Read reads all of the events in a run log.
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			return events, fmt.Errorf("line %d: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// ReadFile reads all of the events in a run log file.
+func ReadFile(filename string) ([]Event, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
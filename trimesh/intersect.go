@@ -0,0 +1,178 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package trimesh
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Mesh is a set of triangles along with their bounds.
+type Mesh struct {
+	Triangles []Triangle
+	Min, Max  mgl.Vec3
+}
+
+// NewMesh returns a mesh of the triangles, which must not be empty.
+func NewMesh(tris []Triangle) *Mesh {
+	m := new(Mesh)
+	m.Triangles = tris
+	m.Min, m.Max = Bounds(tris)
+	return m
+}
+
+// Bounds returns the minimum and maximum corners of the triangles, which
+// must not be empty.
+func Bounds(tris []Triangle) (mgl.Vec3, mgl.Vec3) {
+	min := tris[0][0]
+	max := tris[0][0]
+	for _, t := range tris {
+		min, max = t.extend(min, max)
+	}
+	return min, max
+}
+
+// extend grows the bounds to contain the triangle.
+func (t Triangle) extend(min, max mgl.Vec3) (mgl.Vec3, mgl.Vec3) {
+	for _, v := range t {
+		for i := 0; i < 3; i++ {
+			min[i] = float32(math.Min(float64(min[i]), float64(v[i])))
+			max[i] = float32(math.Max(float64(max[i]), float64(v[i])))
+		}
+	}
+	return min, max
+}
+
+// overlaps returns true if the two boxes overlap.
+func overlaps(minA, maxA, minB, maxB mgl.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if minA[i] > maxB[i] || minB[i] > maxA[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// placed is a triangle moved into world space along with its bounds.
+type placed struct {
+	tri      Triangle
+	min, max mgl.Vec3
+}
+
+// place rotates and then offsets the triangles of the mesh and returns the
+// ones whose bounds overlap the box.
+func (m *Mesh) place(rotation mgl.Quat, offset mgl.Vec3, min, max mgl.Vec3) []placed {
+	var result []placed
+	for _, t := range m.Triangles {
+		var p placed
+		for i, v := range t {
+			p.tri[i] = rotation.Rotate(v).Add(offset)
+		}
+		p.min, p.max = p.tri.extend(p.tri[0], p.tri[0])
+		if overlaps(p.min, p.max, min, max) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// worldBounds returns the bounds of the mesh after it's rotated and then
+// offset, which contain the mesh but may be larger than the tightest ones.
+func (m *Mesh) worldBounds(rotation mgl.Quat, offset mgl.Vec3) (mgl.Vec3, mgl.Vec3) {
+	var min, max mgl.Vec3
+	for i := 0; i < 8; i++ {
+		corner := m.Min
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				corner[axis] = m.Max[axis]
+			}
+		}
+		corner = rotation.Rotate(corner).Add(offset)
+		if i == 0 {
+			min, max = corner, corner
+			continue
+		}
+		min, max = Triangle{corner, corner, corner}.extend(min, max)
+	}
+	return min, max
+}
+
+// Intersect returns true if any of the triangles of mesh a, rotated by
+// rotationA and then offset by offsetA, cross any of the triangles of mesh
+// b placed the same way with rotationB and offsetB.
+//
+// Only the surfaces are tested, so a mesh entirely inside the other doesn't
+// intersect it. Only the triangles inside the overlap of the bounds of the
+// two meshes are tested against each other, so meshes that barely touch are
+// cheap to test.
+func Intersect(a *Mesh, rotationA mgl.Quat, offsetA mgl.Vec3, b *Mesh, rotationB mgl.Quat, offsetB mgl.Vec3) bool {
+	minA, maxA := a.worldBounds(rotationA, offsetA)
+	minB, maxB := b.worldBounds(rotationB, offsetB)
+	if !overlaps(minA, maxA, minB, maxB) {
+		return false
+	}
+
+	// the region both meshes could have triangles in
+	var min, max mgl.Vec3
+	for i := 0; i < 3; i++ {
+		min[i] = float32(math.Max(float64(minA[i]), float64(minB[i])))
+		max[i] = float32(math.Min(float64(maxA[i]), float64(maxB[i])))
+	}
+
+	trisA := a.place(rotationA, offsetA, min, max)
+	if len(trisA) == 0 {
+		return false
+	}
+	trisB := b.place(rotationB, offsetB, min, max)
+	for _, ta := range trisA {
+		for _, tb := range trisB {
+			if overlaps(ta.min, ta.max, tb.min, tb.max) && TrianglesIntersect(ta.tri, tb.tri) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TrianglesIntersect returns true if the two triangles cross, which is when
+// an edge of one passes through the other. Coplanar triangles that overlap
+// are not detected, which doesn't matter for closed meshes since other
+// triangles of the meshes will cross.
+func TrianglesIntersect(a, b Triangle) bool {
+	for i := 0; i < 3; i++ {
+		if segmentCrosses(a[i], a[(i+1)%3], b) || segmentCrosses(b[i], b[(i+1)%3], a) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentCrosses returns true if the segment from p to q passes through the
+// triangle, using the Möller-Trumbore ray-triangle test.
+func segmentCrosses(p, q mgl.Vec3, t Triangle) bool {
+	dir := q.Sub(p)
+	e1 := t[1].Sub(t[0])
+	e2 := t[2].Sub(t[0])
+	h := dir.Cross(e2)
+	det := e1.Dot(h)
+	if det == 0.0 {
+		// the segment is parallel to the triangle
+		return false
+	}
+
+	inv := 1.0 / det
+	s := p.Sub(t[0])
+	u := s.Dot(h) * inv
+	if u < 0.0 || u > 1.0 {
+		return false
+	}
+	sq := s.Cross(e1)
+	v := dir.Dot(sq) * inv
+	if v < 0.0 || u+v > 1.0 {
+		return false
+	}
+	along := e2.Dot(sq) * inv
+	return along >= 0.0 && along <= 1.0
+}
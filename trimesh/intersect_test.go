@@ -0,0 +1,96 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package trimesh
+
+import (
+	"math"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// testWing returns a delta wing pointing down +Z: a flat triangle from
+// wingtip to wingtip with a low ridge along its middle. Its leading edges
+// are swept back so the corners of its bounds are empty space.
+func testWing() *Mesh {
+	left := mgl.Vec3{-1.0, 0.0, -0.5}
+	right := mgl.Vec3{1.0, 0.0, -0.5}
+	nose := mgl.Vec3{0.0, 0.0, 0.5}
+	ridge := mgl.Vec3{0.0, 0.1, -0.3}
+	return NewMesh([]Triangle{
+		{left, right, nose},
+		{left, ridge, right},
+		{right, ridge, nose},
+		{nose, ridge, left},
+	})
+}
+
+// testBomb returns an octahedron standing in for a bomb of the radius.
+func testBomb(radius float32) *Mesh {
+	var tris []Triangle
+	for _, x := range []float32{-radius, radius} {
+		for _, y := range []float32{-radius, radius} {
+			for _, z := range []float32{-radius, radius} {
+				tris = append(tris, Triangle{{x, 0.0, 0.0}, {0.0, y, 0.0}, {0.0, 0.0, z}})
+			}
+		}
+	}
+	return NewMesh(tris)
+}
+
+// sphereHitsBox returns true if the sphere overlaps the box, like the coarse
+// colliders of the bomb and the ship.
+func sphereHitsBox(center mgl.Vec3, radius float32, min, max mgl.Vec3) bool {
+	var distSq float64
+	for i := 0; i < 3; i++ {
+		closest := math.Max(float64(min[i]), math.Min(float64(center[i]), float64(max[i])))
+		d := float64(center[i]) - closest
+		distSq += d * d
+	}
+	return distSq <= float64(radius*radius)
+}
+
+func TestIntersectGraze(t *testing.T) {
+	const bombRadius = 0.3
+	wing := testWing()
+	bomb := testBomb(bombRadius)
+	ident := mgl.QuatIdent()
+
+	tests := []struct {
+		name     string
+		location mgl.Vec3
+		hit      bool
+	}{
+		// beside the right leading edge, where the bounds of the wing are
+		// empty, the coarse colliders hit but the triangles don't.
+		{"graze", mgl.Vec3{0.75, 0.05, 0.35}, false},
+		{"wing", mgl.Vec3{0.2, 0.05, 0.0}, true},
+		{"wingtip", mgl.Vec3{0.9, 0.05, -0.45}, true},
+		{"clear", mgl.Vec3{0.0, 0.0, 2.0}, false},
+	}
+
+	for _, test := range tests {
+		if test.name != "clear" && !sphereHitsBox(test.location, bombRadius, wing.Min, wing.Max) {
+			t.Fatalf("%s: the coarse colliders should hit", test.name)
+		}
+		if hit := Intersect(wing, ident, mgl.Vec3{}, bomb, ident, test.location); hit != test.hit {
+			t.Errorf("%s: expected the hit to be %v", test.name, test.hit)
+		}
+		if hit := Intersect(bomb, ident, test.location, wing, ident, mgl.Vec3{}); hit != test.hit {
+			t.Errorf("%s: expected the hit to be %v with the meshes swapped", test.name, test.hit)
+		}
+	}
+}
+
+func TestIntersectRotated(t *testing.T) {
+	wing := testWing()
+	bomb := testBomb(0.3)
+	graze := mgl.Vec3{0.75, 0.05, 0.35}
+
+	// turning the wing around puts the wingtip where the empty space was
+	turned := mgl.QuatRotate(math.Pi, mgl.Vec3{0.0, 1.0, 0.0})
+	if !Intersect(wing, turned, mgl.Vec3{}, bomb, mgl.QuatIdent(), graze) {
+		t.Errorf("the turned wing should hit the bomb")
+	}
+}
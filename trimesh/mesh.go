@@ -0,0 +1,176 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// Package trimesh loads the triangles of the meshes of components and tests
+// them for intersection, for when the colliders of a component are too
+// coarse to stand in for its actual geometry.
+package trimesh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/fizzle/component"
+	"github.com/tbogdala/gombz"
+
+	"github.com/tbogdala/infinigrid/assetbuild"
+)
+
+// lfsPointerHeader is how a git-lfs pointer file starts.
+var lfsPointerHeader = []byte("version https://git-lfs")
+
+// Triangle is the three corners of a triangle of a mesh.
+type Triangle [3]mgl.Vec3
+
+// LoadComponent reads the component file and returns it along with the
+// triangles of all of its meshes, transformed into component space.
+func LoadComponent(filename string) (*component.Component, []Triangle, error) {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := new(component.Component)
+	err = json.Unmarshal(jsonBytes, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	tris, err := ComponentTriangles(filepath.Dir(filename), c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, tris, nil
+}
+
+// ComponentTriangles returns the triangles of all of the meshes of the
+// component, transformed into component space. Each mesh is read from its
+// BinFile, or from its SrcFile if the binary model can't be read, such as
+// when it's a git-lfs pointer. componentDir is the directory of the
+// component file the mesh paths are relative to.
+func ComponentTriangles(componentDir string, c *component.Component) ([]Triangle, error) {
+	var tris []Triangle
+	for _, compMesh := range c.Meshes {
+		verts, faces, err := loadMesh(componentDir, compMesh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the mesh %s: %v", compMesh.Name, err)
+		}
+
+		transform := meshTransform(compMesh)
+		for _, f := range faces {
+			tris = append(tris, Triangle{
+				transform(verts[f[0]]),
+				transform(verts[f[1]]),
+				transform(verts[f[2]]),
+			})
+		}
+	}
+	if len(tris) == 0 {
+		return nil, fmt.Errorf("the component has no triangles")
+	}
+	return tris, nil
+}
+
+// LoadFile returns the triangles of a model file on its own, such as a
+// simplified collision mesh. Files ending in .obj are read as Wavefront OBJ
+// files with all of their objects; anything else is read as a gombz model.
+func LoadFile(filename string) ([]Triangle, error) {
+	var meshes []*gombz.Mesh
+	if strings.EqualFold(filepath.Ext(filename), ".obj") {
+		objects, err := assetbuild.LoadOBJ(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			meshes = append(meshes, obj.Mesh)
+		}
+	} else {
+		meshBytes, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(meshBytes, lfsPointerHeader) {
+			return nil, fmt.Errorf("%s is a git-lfs pointer", filename)
+		}
+		mesh, err := gombz.DecodeMesh(meshBytes)
+		if err != nil {
+			return nil, err
+		}
+		meshes = append(meshes, mesh)
+	}
+
+	var tris []Triangle
+	for _, mesh := range meshes {
+		for _, f := range mesh.Faces {
+			tris = append(tris, Triangle{mesh.Vertices[f[0]], mesh.Vertices[f[1]], mesh.Vertices[f[2]]})
+		}
+	}
+	if len(tris) == 0 {
+		return nil, fmt.Errorf("%s has no triangles", filename)
+	}
+	return tris, nil
+}
+
+// loadMesh reads the vertices and faces of the component mesh.
+func loadMesh(componentDir string, compMesh *component.Mesh) ([]mgl.Vec3, []gombz.MeshFace, error) {
+	var binErr error
+	if compMesh.BinFile != "" {
+		meshBytes, err := ioutil.ReadFile(filepath.Join(componentDir, filepath.FromSlash(compMesh.BinFile)))
+		if err == nil && bytes.HasPrefix(meshBytes, lfsPointerHeader) {
+			err = fmt.Errorf("%s is a git-lfs pointer", compMesh.BinFile)
+		}
+		if err == nil {
+			var mesh *gombz.Mesh
+			mesh, err = gombz.DecodeMesh(meshBytes)
+			if err == nil {
+				return mesh.Vertices, mesh.Faces, nil
+			}
+		}
+		binErr = err
+	}
+
+	if compMesh.SrcFile == "" {
+		if binErr == nil {
+			binErr = fmt.Errorf("no BinFile or SrcFile")
+		}
+		return nil, nil, binErr
+	}
+	objects, err := assetbuild.LoadOBJ(filepath.Join(componentDir, filepath.FromSlash(compMesh.SrcFile)))
+	if err != nil {
+		if binErr != nil {
+			return nil, nil, fmt.Errorf("%v; %s: %v", binErr, compMesh.SrcFile, err)
+		}
+		return nil, nil, err
+	}
+
+	// the OBJ may hold several meshes so take the matching object if there is one
+	obj := objects[0]
+	for _, o := range objects {
+		if o.Name == compMesh.Name {
+			obj = o
+		}
+	}
+	return obj.Mesh.Vertices, obj.Mesh.Faces, nil
+}
+
+// meshTransform returns a function that scales, rotates and then offsets a
+// vertex of the mesh the way the renderable for it would be.
+func meshTransform(compMesh *component.Mesh) func(v mgl.Vec3) mgl.Vec3 {
+	scale := compMesh.Scale
+	if scale == (mgl.Vec3{}) {
+		scale = mgl.Vec3{1.0, 1.0, 1.0}
+	}
+	rotation := mgl.QuatIdent()
+	if compMesh.RotationDegrees != 0.0 && compMesh.RotationAxis.Len() > 0.0 {
+		rotation = mgl.QuatRotate(mgl.DegToRad(compMesh.RotationDegrees), compMesh.RotationAxis.Normalize())
+	}
+	offset := compMesh.Offset
+
+	return func(v mgl.Vec3) mgl.Vec3 {
+		v = mgl.Vec3{v[0] * scale[0], v[1] * scale[1], v[2] * scale[2]}
+		return rotation.Rotate(v).Add(offset)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	glfwinput "github.com/tbogdala/eweygewey/glfwinput"
 	"github.com/tbogdala/fizzle"
 	"github.com/tbogdala/fizzle/scene"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 const (
@@ -18,6 +20,11 @@ const (
 	uiSystemName     = "UserInterface"
 )
 
+const (
+	// toastDuration is how many seconds each toast is shown for.
+	toastDuration = 4.0
+)
+
 var (
 	fontScale  = 14
 	fontGlyphs = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890., :[]{}\\|<>;\"'~`?/-+_=()*&^%$#@!"
@@ -26,9 +33,33 @@ var (
 // UISystem implements fizzle/scene/System interface and handles the rendering
 // of the user interface.
 type UISystem struct {
-	uiman       *gui.Manager
-	mainMenuWnd *gui.Window
-	visible     bool
+	uiman        *gui.Manager
+	mainMenuWnd  *gui.Window
+	pauseMenuWnd *gui.Window
+	visible      bool
+
+	// hudWnd is drawn while playing even if the rest of the user
+	// interface isn't visible and hudText provides its contents.
+	hudWnd  *gui.Window
+	hudText func() string
+
+	// toasts are notifications shown one after another without stopping
+	// the game; toastWnd shows the first one in the queue.
+	toasts        []uiToast
+	toastWnd      *gui.Window
+	toastTimeLeft float32
+
+	// errorWnd shows the errorLines over everything else until they're
+	// cleared with ShowErrors.
+	errorWnd   *gui.Window
+	errorTitle string
+	errorLines []string
+}
+
+// uiToast is a notification waiting to be shown.
+type uiToast struct {
+	title string
+	text  string
 }
 
 // NewUISystem allocates a new UISystem object.
@@ -50,12 +81,16 @@ func (s *UISystem) Initialize(rs RenderSystem) error {
 	}
 	glfwinput.SetInputHandlers(s.uiman, mainWin)
 
-	// load a font
+	return loadUIFont(s.uiman)
+}
+
+// loadUIFont loads the default font for the user interface manager.
+func loadUIFont(uiman *gui.Manager) error {
 	fontBytes, err := fonts.OswaldHeavyTtfBytes()
 	if err != nil {
 		return fmt.Errorf("Failed to load the embedded font: %v", err)
 	}
-	_, err = s.uiman.NewFontBytes("Default", fontBytes, fontScale, fontGlyphs)
+	_, err = uiman.NewFontBytes("Default", fontBytes, fontScale, fontGlyphs)
 	if err != nil {
 		panic("Failed to load the font file! " + err.Error())
 	}
@@ -68,24 +103,155 @@ func (s *UISystem) SetVisible(vis bool) {
 	s.visible = vis
 }
 
+// closeMainMenu removes the current main menu window if there is one.
+func (s *UISystem) closeMainMenu() {
+	if s.mainMenuWnd != nil {
+		s.uiman.RemoveWindow(s.mainMenuWnd)
+		s.mainMenuWnd = nil
+	}
+}
+
+// styleMenuWindow sets the common window properties for the menus.
+func styleMenuWindow(wnd *gui.Window, title string) {
+	wnd.Title = title
+	wnd.ShowTitleBar = false
+	wnd.IsMoveable = false
+	wnd.AutoAdjustHeight = true
+	wnd.ShowScrollBar = false
+	wnd.IsScrollable = false
+}
+
+// drawHighScores adds the rows of the high score table to the window with
+// the entry at the highlight rank marked as the new record.
+func drawHighScores(wnd *gui.Window, scores *game.HighScoreTable, highlight int) {
+	wnd.StartRow()
+	wnd.Text("HIGH SCORES")
+
+	if scores == nil || len(scores.Entries) == 0 {
+		wnd.StartRow()
+		wnd.Text("No runs recorded yet.")
+		return
+	}
+
+	for i, hs := range scores.Entries {
+		marker := " "
+		if i == highlight {
+			marker = ">"
+		}
+		wnd.StartRow()
+		wnd.Text(fmt.Sprintf("%s%2d. %-12s %6d  %7.1fm  %5.1fs  %-6s  %s", marker, i+1, hs.Name,
+			hs.Score, hs.Distance, hs.Duration, hs.Mode, hs.Date.Format("2006-01-02")))
+	}
+}
+
+// drawOnlineScores adds the rows of the online leaderboard to the window.
+func drawOnlineScores(wnd *gui.Window, online *game.LeaderboardSync) {
+	if online == nil {
+		return
+	}
+
+	wnd.StartRow()
+	wnd.Text("ONLINE LEADERBOARD")
+
+	top, status := online.GetTop()
+	if status != "" {
+		wnd.StartRow()
+		wnd.Text(status)
+	}
+	for i, e := range top {
+		wnd.StartRow()
+		wnd.Text(fmt.Sprintf(" %2d. %-12s %6d  %7.1fm  %5.1fs  %s", i+1, e.Name,
+			e.Score, e.Distance, e.Duration, e.Date.Format("2006-01-02")))
+	}
+}
+
+// ShowMainMenu will render a window letting the user start playing, view the
+// high scores or quit.
+func (s *UISystem) ShowMainMenu(scores *game.HighScoreTable, online *game.LeaderboardSync, onPlay func(), onQuit func()) {
+	s.closeMainMenu()
+	showScores := false
+	s.mainMenuWnd = s.uiman.NewWindow("Menu", 0.3, 0.7, 0.4, 0.25, func(wnd *gui.Window) {
+		wnd.Text("INFINIGRID: ESCAPE")
+
+		if showScores {
+			drawHighScores(wnd, scores, -1)
+			drawOnlineScores(wnd, online)
+		}
+
+		wnd.StartRow()
+		wnd.Separator()
+
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(.33)
+		play, _ := wnd.Button("PlayButton", "Play")
+		wnd.RequestItemWidthMin(.33)
+		toggleScores, _ := wnd.Button("HighScoresButton", "High Scores")
+		wnd.RequestItemWidthMin(.33)
+		quit, _ := wnd.Button("QuitButton", "Quit")
+		wnd.StartRow()
+		wnd.RequestItemWidthMin(.33)
+		textures, _ := wnd.Button("TexturesButton", "Textures: "+gameScene.TextureQuality)
+		wnd.StartRow()
+
+		if textures {
+			gameScene.CycleTextureQuality()
+		}
+
+		if toggleScores {
+			showScores = !showScores
+		}
+
+		if onQuit != nil && quit {
+			s.visible = false
+			onQuit()
+		}
+
+		if onPlay != nil && play {
+			s.visible = false
+			onPlay()
+		}
+	})
+	styleMenuWindow(s.mainMenuWnd, "Menu")
+}
+
 // ShowQuitMenu will render a window with a message prompting the user to replay or quit.
-func (s *UISystem) ShowQuitMenu(onQuit func(), onRetry func()) {
-	s.mainMenuWnd = s.uiman.NewWindow("Menu", 0.4, 0.6, 0.2, 0.25, func(wnd *gui.Window) {
+// If the run placed in the high score table, rank should be its zero-based position
+// in the table and otherwise -1.
+func (s *UISystem) ShowQuitMenu(scores *game.HighScoreTable, rank int, online *game.LeaderboardSync, onQuit func(), onRetry func()) {
+	s.closeMainMenu()
+	showScores := rank >= 0
+	s.mainMenuWnd = s.uiman.NewWindow("Menu", 0.3, 0.7, 0.4, 0.25, func(wnd *gui.Window) {
 		wnd.Text("GAME OVER!")
 
+		if rank == 0 {
+			wnd.StartRow()
+			wnd.Text("NEW RECORD!")
+		}
+
 		wnd.StartRow()
-		wnd.Text(fmt.Sprintf("Distance travelled: %.1f", gameScene.distanceTravelled))
+		wnd.Text(fmt.Sprintf("Distance travelled: %.1f", gameScene.DistanceTravelled()))
+
+		if showScores {
+			drawHighScores(wnd, scores, rank)
+			drawOnlineScores(wnd, online)
+		}
 
 		wnd.StartRow()
 		wnd.Separator()
 
 		wnd.StartRow()
-		wnd.RequestItemWidthMin(.5)
+		wnd.RequestItemWidthMin(.33)
 		quit, _ := wnd.Button("QuitButton", "Quit")
-		wnd.RequestItemWidthMin(.5)
+		wnd.RequestItemWidthMin(.33)
+		toggleScores, _ := wnd.Button("HighScoresButton", "High Scores")
+		wnd.RequestItemWidthMin(.33)
 		replay, _ := wnd.Button("ReplayButton", "Play Again")
 		wnd.StartRow()
 
+		if toggleScores {
+			showScores = !showScores
+		}
+
 		if onQuit != nil && quit {
 			s.visible = false
 			onQuit()
@@ -96,20 +262,116 @@ func (s *UISystem) ShowQuitMenu(onQuit func(), onRetry func()) {
 			onRetry()
 		}
 	})
+	styleMenuWindow(s.mainMenuWnd, "Menu")
+}
+
+// ShowHUD will render a small window in the corner of the screen while
+// playing with the text returned by the function.
+func (s *UISystem) ShowHUD(text func() string) {
+	s.hudText = text
+	if s.hudWnd != nil {
+		return
+	}
+
+	s.hudWnd = s.uiman.NewWindow("HUD", 0.01, 0.99, 0.2, 0.05, func(wnd *gui.Window) {
+		wnd.Text(s.hudText())
+	})
+	styleMenuWindow(s.hudWnd, "HUD")
+}
+
+// ShowToast queues a notification to show at the top of the screen for a few
+// seconds without stopping the game.
+func (s *UISystem) ShowToast(title string, text string) {
+	s.toasts = append(s.toasts, uiToast{title: title, text: text})
+}
+
+// updateToasts removes the current toast once it has been shown long enough
+// and shows the next one in the queue.
+func (s *UISystem) updateToasts(frameDelta float32) {
+	if s.toastWnd != nil {
+		s.toastTimeLeft -= frameDelta
+		if s.toastTimeLeft > 0.0 {
+			return
+		}
+		s.uiman.RemoveWindow(s.toastWnd)
+		s.toastWnd = nil
+		s.toasts = s.toasts[1:]
+	}
+	if len(s.toasts) == 0 {
+		return
+	}
+
+	toast := s.toasts[0]
+	s.toastTimeLeft = toastDuration
+	s.toastWnd = s.uiman.NewWindow("Toast", 0.3, 0.99, 0.4, 0.1, func(wnd *gui.Window) {
+		wnd.Text(toast.title)
+		wnd.StartRow()
+		wnd.Text(toast.text)
+	})
+	styleMenuWindow(s.toastWnd, "Toast")
+}
+
+// ShowErrors will render a window at the bottom of the screen with the error
+// lines until it's called again with no lines.
+func (s *UISystem) ShowErrors(title string, lines []string) {
+	s.errorTitle = title
+	s.errorLines = lines
+	if len(lines) == 0 {
+		if s.errorWnd != nil {
+			s.uiman.RemoveWindow(s.errorWnd)
+			s.errorWnd = nil
+		}
+		return
+	}
+	if s.errorWnd != nil {
+		return
+	}
+
+	s.errorWnd = s.uiman.NewWindow("Errors", 0.01, 0.3, 0.98, 0.29, func(wnd *gui.Window) {
+		wnd.Text(s.errorTitle)
+		for _, line := range s.errorLines {
+			wnd.StartRow()
+			wnd.Text(line)
+		}
+	})
+	styleMenuWindow(s.errorWnd, "Errors")
+}
 
-	s.mainMenuWnd.Title = "Menu"
-	s.mainMenuWnd.ShowTitleBar = false
-	s.mainMenuWnd.IsMoveable = false
-	s.mainMenuWnd.AutoAdjustHeight = true
-	s.mainMenuWnd.ShowScrollBar = false
-	s.mainMenuWnd.IsScrollable = false
+// ShowPauseMenu will render a window letting the user know the game is paused.
+func (s *UISystem) ShowPauseMenu() {
+	if s.pauseMenuWnd != nil {
+		return
+	}
+
+	s.pauseMenuWnd = s.uiman.NewWindow("PauseMenu", 0.4, 0.6, 0.2, 0.25, func(wnd *gui.Window) {
+		wnd.Text("PAUSED")
+
+		wnd.StartRow()
+		wnd.Text("Press P to resume.")
+	})
+	styleMenuWindow(s.pauseMenuWnd, "Paused")
+}
+
+// HidePauseMenu removes the window shown by ShowPauseMenu and hides the user interface.
+func (s *UISystem) HidePauseMenu() {
+	if s.pauseMenuWnd != nil {
+		s.uiman.RemoveWindow(s.pauseMenuWnd)
+		s.pauseMenuWnd = nil
+	}
+	s.visible = false
 }
 
 // Update should get called to run updates for the system every frame
 // by the owning Manager object.
 func (s *UISystem) Update(frameDelta float32) {
+	// menus are removed once hidden so that only the HUD and toasts get drawn
+	if !s.visible {
+		s.closeMainMenu()
+	}
+	s.updateToasts(frameDelta)
+
 	// draw the user interface if visible
-	if s.visible {
+	if s.visible || s.hudWnd != nil || s.toastWnd != nil || s.errorWnd != nil {
 		gfx := fizzle.GetGraphics()
 		width, height := s.uiman.GetResolution()
 		gfx.Viewport(0, 0, int32(width), int32(height))
@@ -134,3 +396,40 @@ func (s *UISystem) GetRequestedPriority() float32 { return uiSystemPriority }
 // GetName returns the name of the system that can be used to identify
 // the System within Manager.
 func (s *UISystem) GetName() string { return uiSystemName }
+
+// SubscribeEvents shows the menus, toasts and errors of the game when
+// the events of the scene call for them.
+func (s *UISystem) SubscribeEvents(events *game.EventBus) {
+	events.OnMainMenu(func() {
+		s.SetVisible(true)
+		s.ShowMainMenu(gameScene.HighScores, gameScene.Leaderboard,
+			events.PublishPlayRequested, events.PublishQuitRequested)
+	})
+	events.OnPlayerDied(func(e *game.PlayerDiedEvent) {
+		s.SetVisible(true)
+		s.ShowQuitMenu(gameScene.HighScores, e.Rank, gameScene.Leaderboard,
+			events.PublishQuitRequested, events.PublishRestartRequested)
+	})
+	events.OnStateChanged(func(e *game.StateChangedEvent) {
+		if e.To == game.GameStatePaused {
+			s.SetVisible(true)
+			s.ShowPauseMenu()
+		} else if e.From == game.GameStatePaused && e.To == game.GameStatePlaying {
+			s.HidePauseMenu()
+		}
+	})
+	events.OnRunStarted(func(e *game.RunStartedEvent) {
+		if e.HasGhost {
+			s.ShowHUD(func() string {
+				delta, _ := gameScene.GhostDelta()
+				return game.FormatGhostDelta(delta)
+			})
+		}
+	})
+	events.OnToast(func(e *game.ToastEvent) {
+		s.ShowToast(e.Title, e.Text)
+	})
+	events.OnAssetErrors(func(e *game.AssetErrorsEvent) {
+		s.ShowErrors(e.Title, e.Lines)
+	})
+}
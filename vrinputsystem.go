@@ -4,11 +4,11 @@
 package main
 
 import (
-	"fmt"
-
 	mgl "github.com/go-gl/mathgl/mgl32"
 	scene "github.com/tbogdala/fizzle/scene"
 	vr "github.com/tbogdala/openvr-go"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 /* Notes on controller state:
@@ -25,6 +25,11 @@ const (
 	vrInputSystemName     = "VRInputSystem"
 )
 
+var (
+	// vrSeatOffset is where the HMD sits relative to the ship.
+	vrSeatOffset = mgl.Vec3{0.0, 0.2, -0.5}
+)
+
 // VRInputSystem implements the fizzle/scene/System interface and handles the
 // player input.
 //
@@ -64,11 +69,20 @@ type VRInputSystem struct {
 	// vrRenderSystem is the cached vrrender system object for the game
 	vrRenderSystem *VRRenderSystem
 
-	// playerEntity is the cached reference to the player entity.
-	playerEntity *VisibleEntity
+	// controller is the ship controller that receives the vr intents.
+	controller *game.ShipController
+
+	// boost is the trigger value of the first controller found this frame.
+	boost float32
 
-	// playerShipEntity is the cached reference to the player ship pawn.
-	playerShipEntity *ShipEntity
+	// playerEntity is the cached reference to the player entity, which is
+	// attached to the ship.
+	playerEntity *game.VisibleEntity
+
+	// events is the event bus of the scene the system was added to and
+	// waitingForRestart is true from the player dying until the next run.
+	events            *game.EventBus
+	waitingForRestart bool
 }
 
 // NewVRInputSystem creates a new InputSystem object
@@ -78,8 +92,9 @@ func NewVRInputSystem() *VRInputSystem {
 }
 
 // Initialize sets up the input models for the scene.
-func (s *VRInputSystem) Initialize(vrRenderSystem *VRRenderSystem) {
+func (s *VRInputSystem) Initialize(vrRenderSystem *VRRenderSystem, controller *game.ShipController) {
 	s.vrRenderSystem = vrRenderSystem
+	s.controller = controller
 	s.vrSystem = s.vrRenderSystem.GetVRSystem()
 	s.vrCompositor = s.vrRenderSystem.GetVRCompositor()
 }
@@ -90,6 +105,7 @@ func (s *VRInputSystem) Update(frameDelta float32) {
 	var controllerState vr.ControllerState
 
 	var foundLeft bool
+	s.boost = 0.0
 	// find the first controller connected and check its buttons
 	for i := vr.TrackedDeviceIndexHmd + 1; i < vr.MaxTrackedDeviceCount; i++ {
 		deviceClass := s.vrSystem.GetTrackedDeviceClass(int(i))
@@ -128,6 +144,11 @@ func (s *VRInputSystem) Update(frameDelta float32) {
 			}
 		}
 
+		// the trigger on the first controller boosts the ship
+		if !foundLeft {
+			s.boost = controllerState.Axis[1].X
+		}
+
 		// are we sending axis data out to a callback event?
 		if foundLeft && s.OnControllerAxisUpdateR != nil {
 			s.OnControllerAxisUpdateR(controllerState.Axis)
@@ -142,19 +163,19 @@ func (s *VRInputSystem) Update(frameDelta float32) {
 		foundLeft = true
 	}
 
-	// if the game state is in the player died state do not move the player
-	if gameScene.gameState == gameStatePlayerDied {
+	// if the game state is not in the playing state do not move the player
+	if gameScene.State() != game.GameStatePlaying {
 		return
 	}
 
-	// after updating the controller state, adjust the player position
+	// after updating the controller state, steer the player's ship
 	// based on the input.
-	s.movePlayer(frameDelta)
+	s.steerPlayer()
 }
 
-// movePlayer moves the ship in the world x/y axis at a speed determined
-// by the proportion of current roll/pitch to the maximum values.
-func (s *VRInputSystem) movePlayer(frameDelta float32) {
+// steerPlayer sends the orientation of the first controller found to the
+// ship controller as the absolute roll and pitch for the ship.
+func (s *VRInputSystem) steerPlayer() {
 	var orientation mgl.Vec3
 	for i := vr.TrackedDeviceIndexHmd + 1; i < vr.MaxTrackedDeviceCount; i++ {
 		deviceClass := s.vrSystem.GetTrackedDeviceClass(int(i))
@@ -172,43 +193,27 @@ func (s *VRInputSystem) movePlayer(frameDelta float32) {
 		orientation = controllerPose.DeviceToAbsoluteTracking.Mul4x1(forward) //vec3 return
 		break
 	}
-	rollInput := orientation[0] // axisData[0].X
-	s.playerShipEntity.currentShipRoll = -rollInput * maxRollRads
-	pitchInput := orientation[2] // axisData[0].Y
-	s.playerShipEntity.currentShipPitch = pitchInput * maxPitchRads
-
-	// rotate the ship
-	qRoll := mgl.QuatRotate(s.playerShipEntity.currentShipRoll, mgl.Vec3{0.0, 0.0, 1.0})
-	qPitch := mgl.QuatRotate(s.playerShipEntity.currentShipPitch, mgl.Vec3{1.0, 0.0, 0.0})
-	s.playerShipEntity.SetOrientation(qRoll.Mul(qPitch))
-
-	// move the ship around
-	rollRatio := s.playerShipEntity.currentShipRoll / maxRollRads
-	pitchRatio := s.playerShipEntity.currentShipPitch / maxPitchRads
-	const moveSpeed = 20.0 // 1 m/s
-	shipLoc := s.playerShipEntity.GetLocation()
-	shipLoc[0] -= moveSpeed * rollRatio * frameDelta
-	shipLoc[1] -= moveSpeed * pitchRatio * frameDelta
-	s.playerShipEntity.SetLocation(shipLoc)
-
-	// glue the HMD to the ship
-	hmdLoc := s.vrRenderSystem.GetHMDLocation()
-	s.playerEntity.SetLocation(s.playerShipEntity.GetLocation().Add(mgl.Vec3{
-		0.0 - hmdLoc[0],
-		0.2 - hmdLoc[1],
-		-0.5 - hmdLoc[2]}))
+	s.controller.AddIntent(game.ShipIntent{
+		Roll:     -orientation[0], // axisData[0].X
+		Pitch:    orientation[2],  // axisData[0].Y
+		Boost:    s.boost,
+		Absolute: true,
+	})
+}
+
+// HandleShipMoved should be invoked after the ship controller moves the ship
+// so that the HMD stays glued to the ship.
+func (s *VRInputSystem) HandleShipMoved() {
+	// the player moves with the ship so only the HMD needs to be glued
+	s.HandleHeadAutoLevel()
 }
 
 // HandleMenuButtonInput should be invoked when the top menu button on the
 // vive controller is pressed.
 func (s *VRInputSystem) HandleMenuButtonInput() {
-	// if the game is in the PlayerDied state, this button will reset the game.
-	if gameScene.gameState == gameStatePlayerDied {
-		err := gameScene.ResetScene()
-		if err != nil {
-			fmt.Printf("Could not reset the game: %v\n", err)
-			gameScene.ShouldClose = true
-		}
+	// if the player has died, this button will restart the game.
+	if s.waitingForRestart && s.events != nil {
+		s.events.PublishRestartRequested()
 		return
 	}
 
@@ -216,39 +221,42 @@ func (s *VRInputSystem) HandleMenuButtonInput() {
 	s.HandleHeadAutoLevel()
 }
 
+// SubscribeEvents keeps track of whether the player is waiting to restart
+// so that the menu button can request it.
+func (s *VRInputSystem) SubscribeEvents(events *game.EventBus) {
+	s.events = events
+	events.OnPlayerDied(func(e *game.PlayerDiedEvent) { s.waitingForRestart = true })
+	events.OnRunStarted(func(e *game.RunStartedEvent) { s.waitingForRestart = false })
+}
+
 // HandleHeadAutoLevel should be called to set the auto-level 'head' position. This allows
 // for the HMD to move around and affect the camera, but be centered appropriately for a
 // sitting position.
 func (s *VRInputSystem) HandleHeadAutoLevel() {
-	// update the playerEntity's height to account for a new calibration of
-	// the HMD head position
+	if s.playerEntity == nil {
+		return
+	}
+
+	// update the playerEntity's offset from the ship to account for a new
+	// calibration of the HMD head position
 	hmdLoc := s.vrRenderSystem.GetHMDLocation()
-	s.playerEntity.SetLocation(s.playerShipEntity.GetLocation().Add(mgl.Vec3{
-		0.0 - hmdLoc[0],
-		0.2 - hmdLoc[1],
-		-0.5 - hmdLoc[2]}))
+	s.playerEntity.SetLocalLocation(vrSeatOffset.Sub(hmdLoc))
 }
 
 // OnAddEntity should get called by the scene Manager each time a new entity
 // has been added to the scene.
 func (s *VRInputSystem) OnAddEntity(newEntity scene.Entity) {
-	// we cache the player and their ship for moving around based on input
-	name := newEntity.GetName()
-	if name == playerEntityName {
-		s.playerEntity = newEntity.(*VisibleEntity)
-	} else if name == playerShipEntityName {
-		s.playerShipEntity = newEntity.(*ShipEntity)
+	// we cache the player for moving around based on input
+	if newEntity.GetName() == game.PlayerEntityName {
+		s.playerEntity = newEntity.(*game.VisibleEntity)
 	}
 }
 
 // OnRemoveEntity should get called by the scene Manager each time an entity
 // has been removed from the scene.
 func (s *VRInputSystem) OnRemoveEntity(oldEntity scene.Entity) {
-	name := oldEntity.GetName()
-	if name == playerEntityName {
+	if oldEntity.GetName() == game.PlayerEntityName {
 		s.playerEntity = nil
-	} else if name == playerShipEntityName {
-		s.playerShipEntity = nil
 	}
 }
 
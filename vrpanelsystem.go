@@ -0,0 +1,245 @@
+// Copyright 2017, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+
+	gui "github.com/tbogdala/eweygewey"
+	fizzle "github.com/tbogdala/fizzle"
+	graphics "github.com/tbogdala/fizzle/graphicsprovider"
+	forward "github.com/tbogdala/fizzle/renderer/forward"
+	scene "github.com/tbogdala/fizzle/scene"
+	fizzlevr "github.com/tbogdala/openvr-go/util/fizzlevr"
+
+	"github.com/tbogdala/infinigrid/game"
+)
+
+const (
+	vrPanelSystemPriority = 80.0
+	vrPanelSystemName     = "VRPanelSystem"
+
+	// the size of the texture the panel user interface is drawn to
+	vrPanelTexWidth  = 512
+	vrPanelTexHeight = 128
+
+	// the size of the panel in the world and where it floats relative to
+	// the player, in meters.
+	vrPanelWidth  = 1.0
+	vrPanelHeight = 0.25
+
+	// vrPanelHUDHeight is the fraction of the panel along the bottom that
+	// the HUD is drawn in.
+	vrPanelHUDHeight = 0.3
+)
+
+var (
+	vrPanelOffset = mgl.Vec3{0.0, 0.4, 1.5}
+)
+
+// VRPanelSystem implements fizzle/scene/System interface and shows toasts and
+// the HUD on a panel floating in front of the player in VR, where there's no
+// screen space user interface.
+type VRPanelSystem struct {
+	renderSystem *VRRenderSystem
+	uiman        *gui.Manager
+	framebuffer  *fizzlevr.EyeFramebuffer
+	panel        *fizzle.Renderable
+
+	toasts        []uiToast
+	toastWnd      *gui.Window
+	toastTimeLeft float32
+
+	// hudWnd is shown along the bottom of the panel below the toasts
+	// while hudText is set.
+	hudText func() string
+	hudWnd  *gui.Window
+
+	// cachedPlayerEntity is the player entity that was added to the scene.
+	cachedPlayerEntity scene.Entity
+}
+
+// NewVRPanelSystem allocates a new VRPanelSystem object.
+func NewVRPanelSystem() *VRPanelSystem {
+	s := new(VRPanelSystem)
+	return s
+}
+
+// Initialize creates the user interface manager, the texture it's drawn to
+// and the panel that shows the texture in the world.
+func (s *VRPanelSystem) Initialize(rs *VRRenderSystem) error {
+	s.renderSystem = rs
+
+	s.uiman = gui.NewManager(fizzle.GetGraphics())
+	err := s.uiman.Initialize(gui.VertShader330, gui.FragShader330, vrPanelTexWidth, vrPanelTexHeight, vrPanelTexHeight)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize the VR panel user interface! " + err.Error())
+	}
+	err = loadUIFont(s.uiman)
+	if err != nil {
+		return err
+	}
+
+	// only one of the render targets is needed for the panel
+	s.framebuffer, _ = fizzlevr.CreateStereoRenderTargets(vrPanelTexWidth, vrPanelTexHeight)
+	gfx := fizzle.GetGraphics()
+	gfx.BindTexture(graphics.TEXTURE_2D, s.framebuffer.ResolveTexture)
+	gfx.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_COMPARE_MODE, graphics.NONE)
+	gfx.BindTexture(graphics.TEXTURE_2D, 0)
+
+	// the plane faces down -Z after being turned around so that the
+	// player looking down +Z sees the front of it.
+	s.panel = fizzle.CreatePlaneXY(-vrPanelWidth/2.0, -vrPanelHeight/2.0, vrPanelWidth/2.0, vrPanelHeight/2.0)
+	s.panel.Material = fizzle.NewMaterial()
+	s.panel.Material.DiffuseTex = s.framebuffer.ResolveTexture
+	s.panel.Material.Shader, err = forward.CreateDiffuseUnlitShader()
+	if err != nil {
+		return fmt.Errorf("Failed to create the VR panel: %v", err)
+	}
+	s.panel.LocalRotation = mgl.QuatRotate(math.Pi, mgl.Vec3{0.0, 1.0, 0.0})
+
+	return nil
+}
+
+// ShowToast queues a notification to show on the panel for a few seconds.
+func (s *VRPanelSystem) ShowToast(title string, text string) {
+	s.toasts = append(s.toasts, uiToast{title: title, text: text})
+}
+
+// ShowHUD shows the text returned by the function along the bottom of the
+// panel, updating it every frame.
+func (s *VRPanelSystem) ShowHUD(text func() string) {
+	s.hudText = text
+	if s.hudWnd != nil {
+		return
+	}
+
+	s.hudWnd = s.uiman.NewWindow("HUD", 0.0, vrPanelHUDHeight, 1.0, vrPanelHUDHeight, func(wnd *gui.Window) {
+		wnd.Text(s.hudText())
+	})
+	styleMenuWindow(s.hudWnd, "HUD")
+	s.hudWnd.AutoAdjustHeight = false
+}
+
+// HideHUD removes the HUD from the panel.
+func (s *VRPanelSystem) HideHUD() {
+	if s.hudWnd == nil {
+		return
+	}
+	s.uiman.RemoveWindow(s.hudWnd)
+	s.hudWnd = nil
+	s.hudText = nil
+}
+
+// SubscribeEvents shows the toasts of the scene, the distance to the ghost
+// and any problems reloading the assets on the panel.
+func (s *VRPanelSystem) SubscribeEvents(events *game.EventBus) {
+	events.OnRunStarted(func(e *game.RunStartedEvent) {
+		if !e.HasGhost {
+			s.HideHUD()
+			return
+		}
+		s.ShowHUD(func() string {
+			delta, _ := gameScene.GhostDelta()
+			return game.FormatGhostDelta(delta)
+		})
+	})
+	events.OnToast(func(e *game.ToastEvent) {
+		s.ShowToast(e.Title, e.Text)
+	})
+	events.OnAssetErrors(func(e *game.AssetErrorsEvent) {
+		if len(e.Lines) > 0 {
+			s.ShowToast(e.Title, strings.Join(e.Lines, "\n"))
+		}
+	})
+}
+
+// updateToasts removes the current toast once it has been shown long enough
+// and shows the next one in the queue.
+func (s *VRPanelSystem) updateToasts(frameDelta float32) {
+	if s.toastWnd != nil {
+		s.toastTimeLeft -= frameDelta
+		if s.toastTimeLeft > 0.0 {
+			return
+		}
+		s.uiman.RemoveWindow(s.toastWnd)
+		s.toastWnd = nil
+		s.toasts = s.toasts[1:]
+	}
+	if len(s.toasts) == 0 {
+		return
+	}
+
+	toast := s.toasts[0]
+	s.toastTimeLeft = toastDuration
+	s.toastWnd = s.uiman.NewWindow("Toast", 0.0, 1.0, 1.0, 1.0-vrPanelHUDHeight, func(wnd *gui.Window) {
+		wnd.Text(toast.title)
+		wnd.StartRow()
+		wnd.Text(toast.text)
+	})
+	styleMenuWindow(s.toastWnd, "Toast")
+	s.toastWnd.AutoAdjustHeight = false
+}
+
+// Update draws the current toast and the HUD to the panel texture and places
+// the panel in front of the player.
+func (s *VRPanelSystem) Update(frameDelta float32) {
+	s.updateToasts(frameDelta)
+	if s.toastWnd == nil && s.hudWnd == nil {
+		s.renderSystem.SetWorldPanel(nil)
+		return
+	}
+
+	// draw the user interface to the panel texture
+	gfx := fizzle.GetGraphics()
+	gfx.Enable(graphics.MULTISAMPLE)
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, s.framebuffer.RenderFramebuffer)
+	gfx.Viewport(0, 0, vrPanelTexWidth, vrPanelTexHeight)
+	gfx.ClearColor(0.0, 0.0, 0.0, 0.0)
+	gfx.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+	s.uiman.Construct(float64(frameDelta))
+	s.uiman.Draw()
+	gfx.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	gfx.Disable(graphics.MULTISAMPLE)
+
+	gfx.BindFramebuffer(graphics.READ_FRAMEBUFFER, s.framebuffer.RenderFramebuffer)
+	gfx.BindFramebuffer(graphics.DRAW_FRAMEBUFFER, s.framebuffer.ResolveFramebuffer)
+	gfx.BlitFramebuffer(0, 0, vrPanelTexWidth, vrPanelTexHeight, 0, 0, vrPanelTexWidth, vrPanelTexHeight, graphics.COLOR_BUFFER_BIT, graphics.LINEAR)
+	gfx.BindFramebuffer(graphics.READ_FRAMEBUFFER, 0)
+	gfx.BindFramebuffer(graphics.DRAW_FRAMEBUFFER, 0)
+
+	// float the panel in front of the player
+	if s.cachedPlayerEntity != nil {
+		s.panel.Location = s.cachedPlayerEntity.GetLocation().Add(vrPanelOffset)
+	}
+	s.renderSystem.SetWorldPanel(s.panel)
+}
+
+// OnAddEntity should get called by the scene Manager each time a new entity
+// has been added to the scene.
+func (s *VRPanelSystem) OnAddEntity(newEntity scene.Entity) {
+	if newEntity.GetName() == game.PlayerEntityName {
+		s.cachedPlayerEntity = newEntity
+	}
+}
+
+// OnRemoveEntity should get called by the scene Manager each time an entity
+// has been removed from the scene.
+func (s *VRPanelSystem) OnRemoveEntity(oldEntity scene.Entity) {
+	if oldEntity.GetName() == game.PlayerEntityName {
+		s.cachedPlayerEntity = nil
+	}
+}
+
+// GetRequestedPriority returns the requested priority level for the System
+// which may be of significance to a Manager if they want to order Update() calls.
+func (s *VRPanelSystem) GetRequestedPriority() float32 { return vrPanelSystemPriority }
+
+// GetName returns the name of the system that can be used to identify
+// the System within Manager.
+func (s *VRPanelSystem) GetName() string { return vrPanelSystemName }
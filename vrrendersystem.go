@@ -16,6 +16,8 @@ import (
 	scene "github.com/tbogdala/fizzle/scene"
 	vr "github.com/tbogdala/openvr-go"
 	fizzlevr "github.com/tbogdala/openvr-go/util/fizzlevr"
+
+	"github.com/tbogdala/infinigrid/game"
 )
 
 const (
@@ -55,7 +57,10 @@ type VRRenderSystem struct {
 	visibleEntities []scene.Entity
 
 	// cachedPlayerEntity is the player entity that was added to the scene.
-	cachedPlayerEntity *VisibleEntity
+	cachedPlayerEntity *game.VisibleEntity
+
+	// worldPanel is drawn in the world after the entities if it's non-nil.
+	worldPanel *fizzle.Renderable
 }
 
 // NewVRRenderSystem allocates a new VRRenderSystem object.
@@ -226,6 +231,12 @@ func (rs *VRRenderSystem) initGraphics(title string, w int, h int, rw int, rh in
 	return nil
 }
 
+// SetWorldPanel sets a renderable, such as a user interface panel, to draw in
+// the world on top of the entities. Passing nil stops drawing the panel.
+func (rs *VRRenderSystem) SetWorldPanel(r *fizzle.Renderable) {
+	rs.worldPanel = r
+}
+
 // SetLight puts a light in the specified slot for the renderer.
 func (rs *VRRenderSystem) SetLight(i int, l *forward.Light) {
 	rs.Renderer.ActiveLights[i] = l
@@ -246,12 +257,12 @@ func (rs *VRRenderSystem) GetName() string {
 // OnAddEntity should get called by the scene Manager each time a new entity
 // has been added to the scene.
 func (rs *VRRenderSystem) OnAddEntity(newEntity scene.Entity) {
-	_, okay := newEntity.(RenderableEntity)
+	_, okay := newEntity.(game.RenderableEntity)
 	if okay {
 		rs.visibleEntities = append(rs.visibleEntities, newEntity)
 		// check to see if it's the player entity; if so, cache the reference
-		if newEntity.GetName() == playerEntityName {
-			rs.cachedPlayerEntity, _ = newEntity.(*VisibleEntity)
+		if newEntity.GetName() == game.PlayerEntityName {
+			rs.cachedPlayerEntity, _ = newEntity.(*game.VisibleEntity)
 		}
 	}
 }
@@ -267,7 +278,7 @@ func (rs *VRRenderSystem) OnRemoveEntity(oldEntity scene.Entity) {
 	}
 	rs.visibleEntities = surviving
 
-	if oldEntity.GetName() == playerEntityName {
+	if oldEntity.GetName() == game.PlayerEntityName {
 		rs.cachedPlayerEntity = nil
 	}
 }
@@ -414,12 +425,14 @@ func (rs *VRRenderSystem) renderScene(eye int) {
 	}
 
 	// draw stuff the visible entities
-	for _, e := range rs.visibleEntities {
-		visibleEntity, okay := e.(RenderableEntity)
-		if okay {
-			if r := visibleEntity.GetRenderable(); r != nil {
-				rs.Renderer.DrawRenderable(r, nil, perspective, worldView, camera)
-			}
-		}
+	drawVisibleEntities(rs.visibleEntities, func(r *fizzle.Renderable) {
+		rs.Renderer.DrawRenderable(r, nil, perspective, worldView, camera)
+	})
+
+	// draw the panel over everything else so it can always be read
+	if rs.worldPanel != nil {
+		rs.gfx.Disable(graphics.DEPTH_TEST)
+		rs.Renderer.DrawRenderable(rs.worldPanel, nil, perspective, worldView, camera)
+		rs.gfx.Enable(graphics.DEPTH_TEST)
 	}
 }